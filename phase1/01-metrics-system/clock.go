@@ -0,0 +1,25 @@
+package metrics
+
+import "time"
+
+// Clock provides the current time to time-dependent metrics.
+// Tests can supply their own implementation to make time deterministic.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// systemClock is a Clock backed by time.Now.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockOrDefault returns c, or the system clock if c is nil.
+func clockOrDefault(c Clock) Clock {
+	if c == nil {
+		return systemClock{}
+	}
+	return c
+}
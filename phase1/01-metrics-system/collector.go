@@ -0,0 +1,262 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// DefaultCollectorTimeout is the default time a Collector may take to
+// collect before the registry gives up on it.
+const DefaultCollectorTimeout = 5 * time.Second
+
+// Collector produces samples on demand, for values that are expensive to
+// compute or owned by another system, such as the depth of an external
+// queue. Register a Collector with Registry.RegisterCollector; the registry
+// calls Collect every time it is collected.
+//
+// Implementations must be safe for concurrent use.
+type Collector interface {
+	// Describe returns the descriptors of every metric the collector
+	// produces. It is called once, at registration.
+	Describe() []Desc
+
+	// Collect returns the current samples. Each sample must belong to a
+	// described metric. The registry fills in the type, help, unit,
+	// constant labels and timestamp of samples that leave them unset.
+	// Collect should return promptly once ctx is done.
+	Collect(ctx context.Context) ([]Sample, error)
+}
+
+type collectorTimeoutOption time.Duration
+
+func (o collectorTimeoutOption) applyRegistry(r *Registry) {
+	r.collectorTimeout = time.Duration(o)
+}
+
+// WithCollectorTimeout bounds the time each Collector may take to collect.
+// Without it, DefaultCollectorTimeout applies.
+func WithCollectorTimeout(d time.Duration) RegistryOption {
+	return collectorTimeoutOption(d)
+}
+
+// registeredCollector is a Collector together with its descriptors.
+type registeredCollector struct {
+	collector Collector
+	descs     map[string]Desc
+
+	// running is set while Collect is executing, so that a collector that
+	// hangs past its timeout is not called again until it returns.
+	running atomic.Bool
+}
+
+// RegisterCollector adds a collector to the registry. Its metrics are
+// collected alongside the registered metrics.
+// It returns ErrDuplicateMetric if a described name is already taken, a
+// *NameError if a described name violates the registry's naming policy and
+// an error wrapping ErrInvalidLabelName if a described label name is
+// invalid.
+func (r *Registry) RegisterCollector(c Collector) error {
+	if c == nil {
+		return errors.New("cannot register nil collector")
+	}
+
+	descs := c.Describe()
+	if len(descs) == 0 {
+		return fmt.Errorf("collector %T describes no metrics", c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.load()
+	rc := &registeredCollector{
+		collector: c,
+		descs:     make(map[string]Desc, len(descs)),
+	}
+	for _, desc := range descs {
+		if err := r.checkName(current, desc.Name, desc.Type); err != nil {
+			return err
+		}
+		if err := checkLabelNames(desc); err != nil {
+			return err
+		}
+		if _, exists := rc.descs[desc.Name]; exists {
+			return fmt.Errorf("%w: %s", ErrDuplicateMetric, desc.Name)
+		}
+		rc.descs[desc.Name] = desc.clone()
+	}
+
+	next := current.clone()
+	for name := range rc.descs {
+		next.collectors[name] = rc
+	}
+	r.state.Store(next)
+	return nil
+}
+
+// UnregisterCollector removes a collector from the registry. The
+// collector is identified by the names it describes, which must be exactly
+// the names of a registered collector, so c need not be comparable.
+// It reports whether the collector was registered.
+func (r *Registry) UnregisterCollector(c Collector) bool {
+	if c == nil {
+		return false
+	}
+	descs := c.Describe()
+	if len(descs) == 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.load()
+	rc, ok := current.collectors[descs[0].Name]
+	if !ok || len(rc.descs) != len(descs) {
+		return false
+	}
+	for _, desc := range descs {
+		if current.collectors[desc.Name] != rc {
+			return false
+		}
+	}
+
+	next := current.clone()
+	for name := range rc.descs {
+		delete(next.collectors, name)
+	}
+	r.state.Store(next)
+	return true
+}
+
+// registeredCollectors returns the distinct collectors of s.
+func (s *registryState) registeredCollectors() []*registeredCollector {
+	seen := make(map[*registeredCollector]struct{}, len(s.collectors))
+	collectors := make([]*registeredCollector, 0, len(s.collectors))
+	for _, rc := range s.collectors {
+		if _, ok := seen[rc]; ok {
+			continue
+		}
+		seen[rc] = struct{}{}
+		collectors = append(collectors, rc)
+	}
+	return collectors
+}
+
+// collectCollectors runs the collectors of s concurrently, each bounded by
+// the collector timeout, and returns their combined samples.
+func (r *Registry) collectCollectors(ctx context.Context, s *registryState, now time.Time) ([]Sample, error) {
+	collectors := s.registeredCollectors()
+	if len(collectors) == 0 {
+		return nil, nil
+	}
+
+	timeout := r.collectorTimeout
+	if timeout <= 0 {
+		timeout = DefaultCollectorTimeout
+	}
+
+	results := make([][]Sample, len(collectors))
+	errs := make([]error, len(collectors))
+	var wg sync.WaitGroup
+	wg.Add(len(collectors))
+	for i, rc := range collectors {
+		go func(i int, rc *registeredCollector) {
+			defer wg.Done()
+			results[i], errs[i] = rc.collect(ctx, timeout, now)
+		}(i, rc)
+	}
+	wg.Wait()
+
+	var samples []Sample
+	for _, s := range results {
+		samples = append(samples, s...)
+	}
+	return samples, errors.Join(errs...)
+}
+
+// collect calls the collector with a timeout, recovering from panics, and
+// completes the returned samples from the descriptors. A collector whose
+// previous collection timed out and is still running is skipped without
+// error, so that a callback that never returns fails a single collection
+// rather than every later one.
+func (rc *registeredCollector) collect(ctx context.Context, timeout time.Duration, now time.Time) ([]Sample, error) {
+	if !rc.running.CompareAndSwap(false, true) {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		samples []Sample
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer rc.running.Store(false)
+		defer func() {
+			if p := recover(); p != nil {
+				done <- result{err: fmt.Errorf("%w: %v", ErrCollectorPanic, p)}
+			}
+		}()
+
+		samples, err := rc.collector.Collect(ctx)
+		done <- result{samples: samples, err: err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("collector %s: %w", rc.name(), ctx.Err())
+	}
+	if res.err != nil {
+		return nil, fmt.Errorf("collector %s: %w", rc.name(), res.err)
+	}
+
+	samples := make([]Sample, 0, len(res.samples))
+	var errs []error
+	for _, s := range res.samples {
+		desc, ok := rc.descs[s.Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("collector %s: sample %s was not described", rc.name(), s.Name))
+			continue
+		}
+		if s.Type == 0 {
+			s.Type = desc.Type
+		} else if s.Type != desc.Type {
+			errs = append(errs, fmt.Errorf("collector %s: sample %s is a %s, described as %s",
+				rc.name(), s.Name, s.Type, desc.Type))
+			continue
+		}
+		if s.Help == "" {
+			s.Help = desc.Help
+		}
+		if s.Unit == "" {
+			s.Unit = desc.Unit
+		}
+		if s.Timestamp.IsZero() {
+			s.Timestamp = now
+		}
+		s.Labels = mergeLabels(desc.ConstLabels, s.Labels.clone())
+		samples = append(samples, s)
+	}
+	return samples, errors.Join(errs...)
+}
+
+// name identifies the collector in errors by its first described name.
+func (rc *registeredCollector) name() string {
+	names := make([]string, 0, len(rc.descs))
+	for name := range rc.descs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names[0]
+}
@@ -0,0 +1,302 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// stubCollector is a Collector with configurable behavior.
+type stubCollector struct {
+	descs   []Desc
+	collect func(ctx context.Context) ([]Sample, error)
+	calls   atomic.Int64
+}
+
+func (c *stubCollector) Describe() []Desc {
+	return c.descs
+}
+
+func (c *stubCollector) Collect(ctx context.Context) ([]Sample, error) {
+	c.calls.Inc()
+	return c.collect(ctx)
+}
+
+// descsCollector is a collector of a non-comparable type, which describes
+// its descriptors and collects nothing.
+type descsCollector []Desc
+
+func (c descsCollector) Describe() []Desc                          { return c }
+func (c descsCollector) Collect(context.Context) ([]Sample, error) { return nil, nil }
+
+// TestGaugeFunc tests callback-backed gauges and counters.
+func TestGaugeFunc(t *testing.T) {
+	r := NewRegistry(0)
+
+	depth := 3.0
+	_ = r.RegisterCollector(NewGaugeFunc("queue_depth", func() float64 { return depth },
+		WithHelp("Jobs waiting."), WithConstLabels(Labels{"queue": "default"})))
+	_ = r.RegisterCollector(NewCounterFunc("cache_hits_total", func() float64 { return 42 }))
+	c := NewCounter("jobs_total")
+	c.Inc()
+	_ = r.Register(c)
+
+	depth = 5
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, r); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+
+	want := `# TYPE cache_hits_total counter
+cache_hits_total 42
+# TYPE jobs_total counter
+jobs_total 1
+# HELP queue_depth Jobs waiting.
+# TYPE queue_depth gauge
+queue_depth{queue="default"} 5
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+
+	samples, _ := r.Collect()
+	for _, s := range samples {
+		if s.Timestamp.IsZero() {
+			t.Errorf("sample %s has zero Timestamp", s.Name)
+		}
+	}
+}
+
+// TestRegistry_RegisterCollector tests collector registration.
+func TestRegistry_RegisterCollector(t *testing.T) {
+	gauge := func(name string) Collector {
+		return NewGaugeFunc(name, func() float64 { return 1 })
+	}
+
+	t.Run("name conflicts with metrics", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("taken"))
+
+		if err := r.RegisterCollector(gauge("taken")); !errors.Is(err, ErrDuplicateMetric) {
+			t.Errorf("RegisterCollector() error = %v, want ErrDuplicateMetric", err)
+		}
+
+		_ = r.RegisterCollector(gauge("collected"))
+		if err := r.Register(NewGauge("collected")); !errors.Is(err, ErrDuplicateMetric) {
+			t.Errorf("Register() error = %v, want ErrDuplicateMetric", err)
+		}
+		if err := r.RegisterCollector(gauge("collected")); !errors.Is(err, ErrDuplicateMetric) {
+			t.Errorf("second RegisterCollector() error = %v, want ErrDuplicateMetric", err)
+		}
+	})
+
+	t.Run("validates names", func(t *testing.T) {
+		r := NewRegistry(0, WithNamingPolicy(PrometheusNamingPolicy()))
+
+		if err := r.RegisterCollector(gauge("queue depth")); !errors.Is(err, ErrInvalidMetricName) {
+			t.Errorf("RegisterCollector() error = %v, want ErrInvalidMetricName", err)
+		}
+	})
+
+	t.Run("rejects collectors without descriptors", func(t *testing.T) {
+		var r Registry
+
+		if err := r.RegisterCollector(&stubCollector{}); err == nil {
+			t.Error("RegisterCollector() without descriptors should return error")
+		}
+		if err := r.RegisterCollector(nil); err == nil {
+			t.Error("RegisterCollector(nil) should return error")
+		}
+	})
+
+	t.Run("unregister non-comparable collector", func(t *testing.T) {
+		r := NewRegistry(0)
+		c := descsCollector{{Name: "a", Type: TypeGauge}, {Name: "b", Type: TypeGauge}}
+		_ = r.RegisterCollector(c)
+
+		if r.UnregisterCollector(descsCollector{{Name: "a", Type: TypeGauge}}) {
+			t.Error("UnregisterCollector(subset) = true, want false")
+		}
+		if !r.UnregisterCollector(c) {
+			t.Error("UnregisterCollector() = false, want true")
+		}
+		if got := len(r.Descs()); got != 0 {
+			t.Errorf("len(Descs()) = %d, want 0", got)
+		}
+	})
+
+	t.Run("unregister and clear", func(t *testing.T) {
+		r := NewRegistry(0)
+		c := &stubCollector{
+			descs:   []Desc{{Name: "a", Type: TypeGauge}, {Name: "b", Type: TypeGauge}},
+			collect: func(context.Context) ([]Sample, error) { return nil, nil },
+		}
+		_ = r.RegisterCollector(c)
+
+		if got := len(r.Descs()); got != 2 {
+			t.Errorf("len(Descs()) = %d, want 2", got)
+		}
+		if !r.UnregisterCollector(c) {
+			t.Error("UnregisterCollector() = false, want true")
+		}
+		if r.UnregisterCollector(c) {
+			t.Error("second UnregisterCollector() = true, want false")
+		}
+		if err := r.Register(NewGauge("a")); err != nil {
+			t.Errorf("Register() after UnregisterCollector() error = %v", err)
+		}
+
+		_ = r.RegisterCollector(gauge("z"))
+		r.Clear()
+		if got := len(r.Descs()); got != 0 {
+			t.Errorf("len(Descs()) after Clear() = %d, want 0", got)
+		}
+	})
+
+	t.Run("descriptors are listed", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("b"))
+		_ = r.RegisterCollector(NewGaugeFunc("a", func() float64 { return 0 }, WithUnit("bytes")))
+
+		want := []Desc{
+			{Name: "a", Type: TypeGauge, Unit: "bytes"},
+			{Name: "b", Type: TypeGauge},
+		}
+		if got := r.Descs(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Descs() = %+v, want %+v", got, want)
+		}
+	})
+}
+
+// TestRegistry_CollectorFailures tests that failing collectors do not
+// affect other metrics.
+func TestRegistry_CollectorFailures(t *testing.T) {
+	collect := func(t *testing.T, r *Registry) ([]string, error) {
+		t.Helper()
+
+		samples, err := r.Collect()
+		names := make([]string, 0, len(samples))
+		for _, s := range samples {
+			names = append(names, s.Name)
+		}
+		return names, err
+	}
+
+	t.Run("panic is recovered", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("healthy"))
+		_ = r.RegisterCollector(NewGaugeFunc("broken", func() float64 { panic("boom") }))
+
+		names, err := collect(t, r)
+		if !errors.Is(err, ErrCollectorPanic) {
+			t.Errorf("Collect() error = %v, want ErrCollectorPanic", err)
+		}
+		if !reflect.DeepEqual(names, []string{"healthy"}) {
+			t.Errorf("Collect() names = %v, want [healthy]", names)
+		}
+	})
+
+	t.Run("returned error is reported", func(t *testing.T) {
+		r := NewRegistry(0)
+		errBackend := errors.New("backend down")
+		_ = r.RegisterCollector(&stubCollector{
+			descs:   []Desc{{Name: "remote", Type: TypeGauge}},
+			collect: func(context.Context) ([]Sample, error) { return nil, errBackend },
+		})
+
+		if _, err := collect(t, r); !errors.Is(err, errBackend) {
+			t.Errorf("Collect() error = %v, want %v", err, errBackend)
+		}
+	})
+
+	t.Run("hung collector times out and is not called again", func(t *testing.T) {
+		r := NewRegistry(0, WithCollectorTimeout(10*time.Millisecond))
+		_ = r.Register(NewGauge("healthy"))
+
+		release := make(chan struct{})
+		hung := &stubCollector{
+			descs: []Desc{{Name: "slow", Type: TypeGauge}},
+			collect: func(context.Context) ([]Sample, error) {
+				<-release
+				return []Sample{{Name: "slow", Value: 1}}, nil
+			},
+		}
+		_ = r.RegisterCollector(hung)
+
+		names, err := collect(t, r)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Collect() error = %v, want context.DeadlineExceeded", err)
+		}
+		if !reflect.DeepEqual(names, []string{"healthy"}) {
+			t.Errorf("Collect() names = %v, want [healthy]", names)
+		}
+
+		names, err = collect(t, r)
+		if err != nil {
+			t.Errorf("Collect() while collector is still running error = %v, want it skipped", err)
+		}
+		if !reflect.DeepEqual(names, []string{"healthy"}) {
+			t.Errorf("Collect() while collector is still running names = %v, want [healthy]", names)
+		}
+		if got := hung.calls.Load(); got != 1 {
+			t.Errorf("collector called %d times while hung, want 1", got)
+		}
+
+		close(release)
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			names, _ = collect(t, r)
+			if len(names) == 2 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if !reflect.DeepEqual(names, []string{"healthy", "slow"}) {
+			t.Errorf("Collect() after release names = %v, want [healthy slow]", names)
+		}
+	})
+
+	t.Run("respects context", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.RegisterCollector(&stubCollector{
+			descs: []Desc{{Name: "slow", Type: TypeGauge}},
+			collect: func(ctx context.Context) ([]Sample, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := r.CollectContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("CollectContext() error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("invalid samples are rejected", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.RegisterCollector(&stubCollector{
+			descs: []Desc{{Name: "ok", Type: TypeGauge}},
+			collect: func(context.Context) ([]Sample, error) {
+				return []Sample{
+					{Name: "ok", Value: 1},
+					{Name: "undescribed", Value: 2},
+					{Name: "ok", Type: TypeCounter, Value: 3},
+				}, nil
+			},
+		})
+
+		names, err := collect(t, r)
+		if err == nil {
+			t.Error("Collect() with invalid samples should return error")
+		}
+		if !reflect.DeepEqual(names, []string{"ok"}) {
+			t.Errorf("Collect() names = %v, want [ok]", names)
+		}
+	})
+}
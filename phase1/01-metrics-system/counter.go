@@ -1,22 +1,35 @@
 package metrics
 
-import "go.uber.org/atomic"
+import (
+	"time"
+
+	"go.uber.org/atomic"
+)
 
 // Counter is a monotonically increasing counter metric that is safe for
 // concurrent use by multiple goroutines. The zero value is ready to use.
 type Counter struct {
-	name  string
-	value atomic.Int64
+	name     string
+	desc     *Desc
+	labels   Labels
+	created  time.Time
+	value    atomic.Int64
+	exemplar atomic.Pointer[Exemplar]
+	updateTracker
 }
 
 // Compile-time verification that Counter implements Metric interface.
 var _ Metric = (*Counter)(nil)
 
-// NewCounter creates a new counter metric with the given name.
+// NewCounter creates a new counter metric with the given name and options.
 // The counter starts at 0 and can only be incremented.
-func NewCounter(name string) *Counter {
+func NewCounter(name string, opts ...Option) *Counter {
+	desc := newDesc(name, TypeCounter, nil, opts)
 	return &Counter{
-		name: name,
+		name:    name,
+		desc:    &desc,
+		labels:  desc.ConstLabels,
+		created: time.Now(),
 	}
 }
 
@@ -25,6 +38,23 @@ func (c *Counter) Name() string {
 	return c.name
 }
 
+// Desc returns the descriptor of this counter.
+func (c *Counter) Desc() Desc {
+	return describe(c.name, TypeCounter, c.desc)
+}
+
+// Labels returns a copy of the labels of this counter: its constant labels
+// and, for children of a CounterVec, its variable labels.
+func (c *Counter) Labels() Labels {
+	return c.labels.clone()
+}
+
+// Created returns the time the counter was created, or the zero time for a
+// zero-value Counter.
+func (c *Counter) Created() time.Time {
+	return c.created
+}
+
 // Type returns TypeCounter, indicating this is a counter metric.
 func (c *Counter) Type() MetricType {
 	return TypeCounter
@@ -40,6 +70,7 @@ func (c *Counter) Value() interface{} {
 // This operation is atomic and safe for concurrent use.
 func (c *Counter) Inc() {
 	c.value.Add(1)
+	c.touch()
 }
 
 // Add increments the counter by the given delta.
@@ -50,6 +81,37 @@ func (c *Counter) Add(delta int64) {
 		delta = 0
 	}
 	c.value.Add(delta)
+	c.touch()
+}
+
+// AddWithExemplar increments the counter by the given delta, like Add, and
+// records an exemplar with the given labels, such as a trace ID. Only the
+// most recent exemplar is kept.
+// It returns ErrInvalidExemplar if the labels are not a valid exemplar
+// label set; the counter is incremented regardless.
+func (c *Counter) AddWithExemplar(delta int64, labels Labels) error {
+	if delta < 0 {
+		delta = 0
+	}
+	c.value.Add(delta)
+	c.touch()
+
+	exemplar, err := newExemplar(labels, float64(delta))
+	if err != nil {
+		return err
+	}
+	c.exemplar.Store(exemplar)
+	return nil
+}
+
+// Exemplar returns a copy of the most recently recorded exemplar.
+// It reports false if no exemplar has been recorded.
+func (c *Counter) Exemplar() (Exemplar, bool) {
+	e := c.exemplar.Load()
+	if e == nil {
+		return Exemplar{}, false
+	}
+	return *e.clone(), true
 }
 
 // Load returns the current value of the counter.
@@ -0,0 +1,80 @@
+package metrics
+
+import "time"
+
+// CounterVec is a family of counters that share a name and are
+// distinguished by label values, e.g. http_requests_total{method="GET"}.
+// Children are created on first use and cached. It is safe for concurrent
+// use by multiple goroutines.
+type CounterVec struct {
+	vec *metricVec[*Counter]
+}
+
+// Compile-time verification that CounterVec implements metricFamily.
+var _ metricFamily = (*CounterVec)(nil)
+
+// NewCounterVec creates a new counter vector with the given name, label
+// names and options.
+func NewCounterVec(name string, labelNames []string, opts ...Option) *CounterVec {
+	desc := newDesc(name, TypeCounter, labelNames, opts)
+	return &CounterVec{
+		vec: newMetricVec(desc, func(labels Labels) *Counter {
+			return &Counter{name: name, desc: &desc, labels: labels, created: time.Now()}
+		}),
+	}
+}
+
+// Name returns the name shared by all counters in this vector.
+func (v *CounterVec) Name() string {
+	return v.vec.desc.Name
+}
+
+// Desc returns the descriptor shared by all counters in this vector.
+func (v *CounterVec) Desc() Desc {
+	return v.vec.desc.clone()
+}
+
+// Type returns TypeCounter, the type of every child in this vector.
+func (v *CounterVec) Type() MetricType {
+	return TypeCounter
+}
+
+// Value returns the current values of all children as an interface{}.
+// The underlying type is map[string]interface{}, keyed by the children's
+// label sets as rendered by Labels.String, with int64 values.
+func (v *CounterVec) Value() interface{} {
+	return v.vec.value()
+}
+
+// LabelNames returns a copy of the label names declared for this vector.
+func (v *CounterVec) LabelNames() []string {
+	return append([]string(nil), v.vec.labelNames...)
+}
+
+// WithLabelValues returns the counter for the given label values, given in
+// the order of the declared label names, creating it if needed.
+// It returns ErrLabelMismatch if the number of values is wrong.
+func (v *CounterVec) WithLabelValues(values ...string) (*Counter, error) {
+	return v.vec.withLabelValues(values)
+}
+
+// With returns the counter for the given labels, creating it if needed.
+// It returns ErrLabelMismatch if the labels differ from the declared names.
+func (v *CounterVec) With(labels Labels) (*Counter, error) {
+	return v.vec.with(labels)
+}
+
+// Delete removes the counter for the given labels.
+// It reports whether a counter was removed.
+func (v *CounterVec) Delete(labels Labels) bool {
+	return v.vec.delete(labels)
+}
+
+// Reset removes all counters from this vector.
+func (v *CounterVec) Reset() {
+	v.vec.reset()
+}
+
+func (v *CounterVec) children() []Metric {
+	return v.vec.children()
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+)
+
+// DeltaCollector collects a Registry for push-based backends that expect
+// the change since their previous report, such as StatsD or OTLP with
+// delta temporality, rather than cumulative totals.
+//
+// Each call to Collect returns the samples of the registry with the values
+// of counters, meters, histograms and summaries replaced by their increase
+// since the previous call of the same DeltaCollector:
+//
+//   - Counters: Value is the increase.
+//   - Meters: Count is the increase; rates are unchanged.
+//   - Histograms: bucket counts, Count and Sum are the increase.
+//   - Summaries: Count and Sum are the increase; quantiles are unchanged.
+//
+// Gauges are returned unchanged. The Created time of a delta sample is the
+// start of the interval it covers: the Timestamp of the previous
+// collection, or the creation of the metric for its first delta.
+//
+// The first delta of a series is its whole value. A series is considered
+// reset, and its delta is its whole value again, when its creation time
+// changes, as it does when a metric is unregistered and a new one is
+// registered under the same name, or when its value decreases. A series
+// missing from a collection is forgotten, unless the collection failed.
+//
+// Each consumer should create its own DeltaCollector; they track their
+// previous values independently. A DeltaCollector is safe for concurrent
+// use, calls to Collect being serialized.
+type DeltaCollector struct {
+	registry *Registry
+
+	mu      sync.Mutex
+	tracker deltaTracker
+}
+
+// NewDeltaCollector creates a delta collector for r.
+func NewDeltaCollector(r *Registry) *DeltaCollector {
+	return &DeltaCollector{registry: r}
+}
+
+// Collect returns the samples of the registry with cumulative values
+// replaced by their increase since the previous call. Like
+// Registry.Collect, it returns the samples that could be collected along
+// with an error describing the others.
+func (d *DeltaCollector) Collect() ([]Sample, error) {
+	return d.CollectContext(context.Background())
+}
+
+// CollectContext is like Collect, but stops waiting for collectors once
+// ctx is done.
+func (d *DeltaCollector) CollectContext(ctx context.Context) ([]Sample, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Collecting under the lock orders the collections of concurrent
+	// callers, so that each delta starts where the previous one ended.
+	samples, err := d.registry.CollectContext(ctx)
+	d.tracker.apply(samples, err != nil)
+	return samples, err
+}
+
+// deltaTracker converts successive collections of cumulative samples into
+// deltas. It is not safe for concurrent use.
+type deltaTracker struct {
+	// forgetAfter is the number of consecutive complete collections a
+	// series may be missing from before it is forgotten. Zero forgets it
+	// as soon as it is missing.
+	forgetAfter int
+
+	last map[string]trackedSample // previous cumulative samples by key
+}
+
+// trackedSample is the previous cumulative sample of a series.
+type trackedSample struct {
+	Sample
+	misses int // consecutive complete collections the series was missing from
+}
+
+// apply replaces the cumulative values of samples, in place, by their
+// increase since the previous call. If partial is set, the samples are
+// the result of a failed collection: series missing from them keep their
+// previous values, so that their next delta is not their whole value.
+func (t *deltaTracker) apply(samples []Sample, partial bool) {
+	next := make(map[string]trackedSample, len(t.last))
+	for i, s := range samples {
+		if !isCumulative(s) {
+			continue
+		}
+
+		key := s.key()
+		next[key] = trackedSample{Sample: s}
+		if prev, ok := t.last[key]; ok && !isReset(prev.Sample, s) {
+			samples[i] = delta(prev.Sample, s)
+		}
+	}
+
+	for key, prev := range t.last {
+		if _, ok := next[key]; ok {
+			continue
+		}
+		if !partial {
+			prev.misses++
+		}
+		if prev.misses <= t.forgetAfter {
+			next[key] = prev
+		}
+	}
+	t.last = next
+}
+
+// isCumulative reports whether s holds a cumulative value.
+func isCumulative(s Sample) bool {
+	switch {
+	case s.Histogram != nil, s.Summary != nil, s.Meter != nil:
+		return true
+	default:
+		return s.Type == TypeCounter
+	}
+}
+
+// isReset reports whether cur does not continue prev: it belongs to
+// another metric, or its value decreased.
+func isReset(prev, cur Sample) bool {
+	if prev.Type != cur.Type || !prev.Created.Equal(cur.Created) {
+		return true
+	}
+
+	switch {
+	case cur.Histogram != nil:
+		p, c := prev.Histogram, cur.Histogram
+		if p == nil || c.Count < p.Count || len(c.Buckets) != len(p.Buckets) {
+			return true
+		}
+		for i, b := range c.Buckets {
+			if b.UpperBound != p.Buckets[i].UpperBound || b.Count < p.Buckets[i].Count {
+				return true
+			}
+		}
+		return false
+	case cur.Summary != nil:
+		return prev.Summary == nil || cur.Summary.Count < prev.Summary.Count
+	case cur.Meter != nil:
+		return prev.Meter == nil || cur.Meter.Count < prev.Meter.Count
+	default:
+		return cur.Value < prev.Value
+	}
+}
+
+// delta returns cur with its cumulative values replaced by their increase
+// since prev. The snapshots of cur are copied, not modified.
+func delta(prev, cur Sample) Sample {
+	d := cur
+	d.Created = prev.Timestamp
+
+	switch {
+	case cur.Histogram != nil:
+		h := *cur.Histogram
+		h.Buckets = make([]Bucket, len(cur.Histogram.Buckets))
+		for i, b := range cur.Histogram.Buckets {
+			b.Count -= prev.Histogram.Buckets[i].Count
+			h.Buckets[i] = b
+		}
+		h.Count -= prev.Histogram.Count
+		h.Sum -= prev.Histogram.Sum
+		d.Histogram = &h
+	case cur.Summary != nil:
+		s := *cur.Summary
+		s.Count -= prev.Summary.Count
+		s.Sum -= prev.Summary.Sum
+		d.Summary = &s
+	case cur.Meter != nil:
+		m := *cur.Meter
+		m.Count -= prev.Meter.Count
+		d.Meter = &m
+	default:
+		d.Value -= prev.Value
+	}
+	return d
+}
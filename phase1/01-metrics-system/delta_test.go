@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deltaValues collects d and returns the sample values by key.
+func deltaValues(t *testing.T, d *DeltaCollector) map[string]float64 {
+	t.Helper()
+
+	samples, err := d.Collect()
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		values[s.key()] = s.Value
+	}
+	return values
+}
+
+// TestDeltaCollector tests counter and gauge deltas.
+func TestDeltaCollector(t *testing.T) {
+	r := NewRegistry(0)
+	requests := NewCounterVec("requests_total", []string{"code"})
+	queue := NewGauge("queue_depth")
+	r.MustRegister(requests, queue)
+
+	ok, _ := requests.With(Labels{"code": "200"})
+	ok.Add(5)
+	queue.Set(7)
+
+	d := NewDeltaCollector(r)
+	want := map[string]float64{`requests_total{code="200"}`: 5, "queue_depth": 7}
+	if got := deltaValues(t, d); !equalValues(got, want) {
+		t.Errorf("first Collect() = %v, want %v", got, want)
+	}
+
+	ok.Add(3)
+	failed, _ := requests.With(Labels{"code": "500"})
+	failed.Inc()
+	queue.Set(2)
+	want = map[string]float64{
+		`requests_total{code="200"}`: 3,
+		`requests_total{code="500"}`: 1,
+		"queue_depth":                2,
+	}
+	if got := deltaValues(t, d); !equalValues(got, want) {
+		t.Errorf("second Collect() = %v, want %v", got, want)
+	}
+
+	want = map[string]float64{
+		`requests_total{code="200"}`: 0,
+		`requests_total{code="500"}`: 0,
+		"queue_depth":                2,
+	}
+	if got := deltaValues(t, d); !equalValues(got, want) {
+		t.Errorf("third Collect() = %v, want %v", got, want)
+	}
+}
+
+// equalValues reports whether two value maps are equal.
+func equalValues(a, b map[string]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// TestDeltaCollector_Interval tests the interval a delta covers.
+func TestDeltaCollector_Interval(t *testing.T) {
+	r := NewRegistry(0)
+	c := NewCounter("jobs_total")
+	r.MustRegister(c)
+
+	d := NewDeltaCollector(r)
+	first, _ := d.Collect()
+	if !first[0].Created.Equal(c.Created()) {
+		t.Errorf("first Created = %v, want creation %v", first[0].Created, c.Created())
+	}
+
+	second, _ := d.Collect()
+	if !second[0].Created.Equal(first[0].Timestamp) {
+		t.Errorf("second Created = %v, want previous Timestamp %v", second[0].Created, first[0].Timestamp)
+	}
+}
+
+// TestDeltaCollector_Reset tests counters that restart.
+func TestDeltaCollector_Reset(t *testing.T) {
+	t.Run("re-registered", func(t *testing.T) {
+		r := NewRegistry(0)
+		old := NewCounter("jobs_total")
+		old.Add(10)
+		r.MustRegister(old)
+
+		d := NewDeltaCollector(r)
+		_ = deltaValues(t, d)
+
+		// The replacement has overtaken the old value by the next collection.
+		_ = r.Unregister("jobs_total")
+		replacement := NewCounter("jobs_total")
+		replacement.created = old.created.Add(time.Second)
+		replacement.Add(15)
+		r.MustRegister(replacement)
+
+		if got := deltaValues(t, d)["jobs_total"]; got != 15 {
+			t.Errorf("delta after re-registration = %v, want 15", got)
+		}
+	})
+
+	t.Run("decreased", func(t *testing.T) {
+		r := NewRegistry(0)
+		value := 10.0
+		_ = r.RegisterCollector(NewCounterFunc("hits_total", func() float64 { return value }))
+
+		d := NewDeltaCollector(r)
+		_ = deltaValues(t, d)
+
+		value = 4
+		if got := deltaValues(t, d)["hits_total"]; got != 4 {
+			t.Errorf("delta after decrease = %v, want 4", got)
+		}
+	})
+
+	t.Run("unregistered", func(t *testing.T) {
+		r := NewRegistry(0)
+		c := NewCounter("jobs_total")
+		c.Add(10)
+		r.MustRegister(c)
+
+		d := NewDeltaCollector(r)
+		_ = deltaValues(t, d)
+
+		_ = r.Unregister("jobs_total")
+		if got := deltaValues(t, d); len(got) != 0 {
+			t.Errorf("Collect() = %v, want nothing", got)
+		}
+
+		// The same counter registered again counts as new.
+		r.MustRegister(c)
+		if got := deltaValues(t, d)["jobs_total"]; got != 10 {
+			t.Errorf("delta after registering again = %v, want 10", got)
+		}
+	})
+}
+
+// TestDeltaCollector_Snapshots tests deltas of histograms, summaries and
+// meters.
+func TestDeltaCollector_Snapshots(t *testing.T) {
+	clock := newFakeClock()
+	r := NewRegistry(0)
+	h := NewHistogram("latency_seconds", []float64{0.1, 1})
+	s := NewSummary("size_bytes", SummaryConfig{Clock: clock})
+	m := NewMeter("events_total", MeterConfig{Clock: clock})
+	r.MustRegister(h, s, m)
+
+	h.Observe(0.05)
+	h.Observe(0.5)
+	s.Observe(100)
+	m.Mark(4)
+
+	d := NewDeltaCollector(r)
+	_, _ = d.Collect()
+
+	h.Observe(0.5)
+	h.Observe(5)
+	s.Observe(300)
+	m.Mark(6)
+
+	samples, err := d.Collect()
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	for _, sample := range samples {
+		switch sample.Name {
+		case "latency_seconds":
+			got := sample.Histogram
+			if got.Count != 2 || got.Sum != 5.5 || got.Buckets[0].Count != 0 || got.Buckets[1].Count != 1 {
+				t.Errorf("histogram delta = %+v, want 2 observations summing to 5.5, 0 and 1 in buckets", got)
+			}
+		case "size_bytes":
+			got := sample.Summary
+			if got.Count != 1 || got.Sum != 300 || len(got.Quantiles) == 0 {
+				t.Errorf("summary delta = %+v, want 1 observation of 300 with quantiles", got)
+			}
+		case "events_total":
+			if got := sample.Meter.Count; got != 6 {
+				t.Errorf("meter delta = %d, want 6", got)
+			}
+		}
+	}
+
+	if got := h.Snapshot(); got.Count != 4 || got.Buckets[1].Count != 3 {
+		t.Errorf("histogram changed to %+v by delta collection", got)
+	}
+}
+
+// TestDeltaCollector_Consumers tests independent consumers.
+func TestDeltaCollector_Consumers(t *testing.T) {
+	r := NewRegistry(0)
+	c := NewCounter("jobs_total")
+	r.MustRegister(c)
+
+	fast, slow := NewDeltaCollector(r), NewDeltaCollector(r)
+	c.Add(2)
+	_ = deltaValues(t, fast)
+	c.Add(3)
+	_ = deltaValues(t, fast)
+
+	if got := deltaValues(t, slow)["jobs_total"]; got != 5 {
+		t.Errorf("slow consumer delta = %v, want 5", got)
+	}
+	c.Inc()
+	if got := deltaValues(t, fast)["jobs_total"]; got != 1 {
+		t.Errorf("fast consumer delta = %v, want 1", got)
+	}
+}
+
+// TestDeltaCollector_Failure tests that series missing because of a failed
+// collection keep their previous values.
+func TestDeltaCollector_Failure(t *testing.T) {
+	r := NewRegistry(0)
+	value, fail := 10.0, false
+	_ = r.RegisterCollector(&stubCollector{
+		descs: []Desc{{Name: "hits_total", Type: TypeCounter}},
+		collect: func(context.Context) ([]Sample, error) {
+			if fail {
+				return nil, errors.New("backend down")
+			}
+			return []Sample{{Name: "hits_total", Value: value}}, nil
+		},
+	})
+
+	d := NewDeltaCollector(r)
+	_ = deltaValues(t, d)
+
+	fail = true
+	if samples, err := d.Collect(); err == nil || len(samples) != 0 {
+		t.Fatalf("Collect() = %v, %v, want an error", samples, err)
+	}
+
+	fail, value = false, 12
+	if got := deltaValues(t, d)["hits_total"]; got != 2 {
+		t.Errorf("delta after failure = %v, want 2", got)
+	}
+}
+
+// TestDeltaCollector_Concurrent tests that concurrent consumers of one
+// DeltaCollector see every increment exactly once.
+func TestDeltaCollector_Concurrent(t *testing.T) {
+	const goroutines, rounds = 4, 100
+
+	r := NewRegistry(0)
+	c := NewCounter("jobs_total")
+	r.MustRegister(c)
+	d := NewDeltaCollector(r)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total float64
+	)
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				c.Inc()
+				samples, _ := d.Collect()
+				mu.Lock()
+				total += samples[0].Value
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if total != goroutines*rounds {
+		t.Errorf("sum of deltas = %v, want %d", total, goroutines*rounds)
+	}
+}
@@ -0,0 +1,155 @@
+package metrics
+
+// Desc describes a metric: what it is called, what it measures and which
+// labels it carries. Every Metric exposes its Desc, so exporters can write
+// help text and units without knowing the concrete metric type.
+type Desc struct {
+	// Name is the metric name.
+	Name string
+
+	// Type is the metric type.
+	Type MetricType
+
+	// Help is a human-readable description of the metric, set with
+	// WithHelp.
+	Help string
+
+	// Unit is the unit the metric is measured in, e.g. "seconds" or
+	// "bytes", set with WithUnit.
+	Unit string
+
+	// ConstLabels are labels with fixed values attached to every sample of
+	// the metric, set with WithConstLabels.
+	ConstLabels Labels
+
+	// VariableLabels are the label names of a vector, whose values differ
+	// between children. They are nil for plain metrics.
+	VariableLabels []string
+}
+
+// Option configures the Desc of a metric at construction.
+type Option interface {
+	apply(*Desc)
+}
+
+type helpOption string
+
+func (o helpOption) apply(d *Desc) {
+	d.Help = string(o)
+}
+
+// WithHelp sets the help text of a metric.
+func WithHelp(help string) Option {
+	return helpOption(help)
+}
+
+type unitOption string
+
+func (o unitOption) apply(d *Desc) {
+	d.Unit = string(o)
+}
+
+// WithUnit sets the unit of a metric, e.g. "seconds". By convention the
+// metric name ends with the unit, as in request_duration_seconds;
+// OpenMetrics only exposes the unit in that case.
+func WithUnit(unit string) Option {
+	return unitOption(unit)
+}
+
+type constLabelsOption Labels
+
+func (o constLabelsOption) apply(d *Desc) {
+	if d.ConstLabels == nil {
+		d.ConstLabels = make(Labels, len(o))
+	}
+	for name, value := range o {
+		d.ConstLabels[name] = value
+	}
+}
+
+// WithConstLabels attaches labels with fixed values to every sample of a
+// metric, e.g. {"service": "api"}. Repeated options are merged. On vectors,
+// constant labels that share a name with a variable label are ignored.
+// Registering a metric whose label names are invalid fails with an error
+// wrapping ErrInvalidLabelName.
+func WithConstLabels(labels Labels) Option {
+	return constLabelsOption(labels.clone())
+}
+
+// newDesc builds the Desc of a metric from its constructor arguments.
+func newDesc(name string, t MetricType, labelNames []string, opts []Option) Desc {
+	d := Desc{
+		Name: name,
+		Type: t,
+	}
+	for _, opt := range opts {
+		opt.apply(&d)
+	}
+
+	if labelNames != nil {
+		d.VariableLabels = append([]string(nil), labelNames...)
+		for _, name := range labelNames {
+			delete(d.ConstLabels, name)
+		}
+	}
+	if len(d.ConstLabels) == 0 {
+		d.ConstLabels = nil
+	}
+	return d
+}
+
+// clone returns a deep copy of d.
+func (d Desc) clone() Desc {
+	d.ConstLabels = d.ConstLabels.clone()
+	if d.VariableLabels != nil {
+		d.VariableLabels = append([]string(nil), d.VariableLabels...)
+	}
+	return d
+}
+
+// describer is implemented by metrics that have a descriptor, like those
+// of this package. It is kept out of Metric so that existing
+// implementations of Metric still satisfy it.
+type describer interface {
+	// Desc returns the descriptor of this metric, including its help
+	// text, unit and constant labels.
+	Desc() Desc
+}
+
+// descOf returns the descriptor of m, made of its name and type alone if
+// m has no Desc method.
+func descOf(m Metric) Desc {
+	if d, ok := m.(describer); ok {
+		return d.Desc()
+	}
+	return describe(m.Name(), m.Type(), nil)
+}
+
+// describe returns the Desc of a metric with the given name and type. d is
+// nil for zero-value metrics, which have no options.
+func describe(name string, t MetricType, d *Desc) Desc {
+	var desc Desc
+	if d != nil {
+		desc = d.clone()
+	}
+	desc.Name = name
+	desc.Type = t
+	return desc
+}
+
+// mergeLabels returns the union of the constant labels of a vector and the
+// variable labels of one of its children.
+func mergeLabels(constLabels, labels Labels) Labels {
+	if len(constLabels) == 0 {
+		return labels
+	}
+
+	merged := make(Labels, len(constLabels)+len(labels))
+	for name, value := range constLabels {
+		merged[name] = value
+	}
+	for name, value := range labels {
+		merged[name] = value
+	}
+	return merged
+}
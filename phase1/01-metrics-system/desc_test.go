@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDesc tests metric descriptors and constructor options.
+func TestDesc(t *testing.T) {
+	opts := []Option{
+		WithHelp("Request latency."),
+		WithUnit("seconds"),
+		WithConstLabels(Labels{"service": "api"}),
+	}
+
+	tests := []struct {
+		name   string
+		metric Metric
+		want   Desc
+	}{
+		{
+			name:   "counter",
+			metric: NewCounter("latency_seconds", opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeCounter, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}},
+		},
+		{
+			name:   "gauge",
+			metric: NewGauge("latency_seconds", opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeGauge, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}},
+		},
+		{
+			name:   "histogram",
+			metric: NewHistogram("latency_seconds", nil, opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeHistogram, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}},
+		},
+		{
+			name:   "summary",
+			metric: NewSummary("latency_seconds", SummaryConfig{}, opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeSummary, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}},
+		},
+		{
+			name:   "counter vector",
+			metric: NewCounterVec("latency_seconds", []string{"route"}, opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeCounter, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}, VariableLabels: []string{"route"}},
+		},
+		{
+			name:   "gauge vector",
+			metric: NewGaugeVec("latency_seconds", []string{"route"}, opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeGauge, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}, VariableLabels: []string{"route"}},
+		},
+		{
+			name:   "histogram vector",
+			metric: NewHistogramVec("latency_seconds", []string{"route"}, nil, opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeHistogram, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}, VariableLabels: []string{"route"}},
+		},
+		{
+			name:   "summary vector",
+			metric: NewSummaryVec("latency_seconds", []string{"route"}, SummaryConfig{}, opts...),
+			want:   Desc{Name: "latency_seconds", Type: TypeSummary, Help: "Request latency.", Unit: "seconds", ConstLabels: Labels{"service": "api"}, VariableLabels: []string{"route"}},
+		},
+		{
+			name:   "no options",
+			metric: NewCounter("requests_total"),
+			want:   Desc{Name: "requests_total", Type: TypeCounter},
+		},
+		{
+			name:   "zero value",
+			metric: &Gauge{},
+			want:   Desc{Type: TypeGauge},
+		},
+		{
+			name:   "metric without Desc method",
+			metric: int32Metric{},
+			want:   Desc{Name: "custom", Type: TypeGauge},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := descOf(tt.metric); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Desc() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDesc_Copies tests that descriptors cannot be mutated through
+// options or returned values.
+func TestDesc_Copies(t *testing.T) {
+	labels := Labels{"service": "api"}
+	c := NewCounter("requests_total", WithConstLabels(labels))
+	labels["service"] = "changed"
+
+	desc := c.Desc()
+	desc.ConstLabels["service"] = "mutated"
+
+	if got := c.Desc().ConstLabels["service"]; got != "api" {
+		t.Errorf("ConstLabels[service] = %q, want %q", got, "api")
+	}
+	if got := c.Labels()["service"]; got != "api" {
+		t.Errorf("Labels()[service] = %q, want %q", got, "api")
+	}
+}
+
+// TestWithConstLabels tests constant labels on plain metrics and vectors.
+func TestWithConstLabels(t *testing.T) {
+	t.Run("repeated options are merged", func(t *testing.T) {
+		g := NewGauge("up", WithConstLabels(Labels{"a": "1"}), WithConstLabels(Labels{"b": "2"}))
+
+		want := Labels{"a": "1", "b": "2"}
+		if got := g.Labels(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Labels() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("vector children carry constant labels", func(t *testing.T) {
+		v := NewCounterVec("requests_total", []string{"method"}, WithConstLabels(Labels{"service": "api"}))
+		c, _ := v.WithLabelValues("GET")
+
+		want := Labels{"method": "GET", "service": "api"}
+		if got := c.Labels(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Labels() = %v, want %v", got, want)
+		}
+
+		// The full label set of a child selects that child.
+		same, err := v.With(c.Labels())
+		if err != nil || same != c {
+			t.Errorf("With(child labels) = %p, %v, want %p", same, err, c)
+		}
+		if _, err := v.With(Labels{"method": "GET", "service": "other"}); err == nil {
+			t.Error("With() with wrong constant label value should return error")
+		}
+		if !v.Delete(c.Labels()) {
+			t.Error("Delete(child labels) = false, want true")
+		}
+	})
+
+	t.Run("variable labels take precedence", func(t *testing.T) {
+		v := NewGaugeVec("temperature", []string{"room"}, WithConstLabels(Labels{"room": "lobby", "site": "hq"}))
+		g, _ := v.WithLabelValues("kitchen")
+
+		want := Labels{"room": "kitchen", "site": "hq"}
+		if got := g.Labels(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Labels() = %v, want %v", got, want)
+		}
+		if got := v.Desc().ConstLabels; !reflect.DeepEqual(got, Labels{"site": "hq"}) {
+			t.Errorf("Desc().ConstLabels = %v, want {site=hq}", got)
+		}
+	})
+}
+
+// TestRegistry_Descs tests listing registered descriptors.
+func TestRegistry_Descs(t *testing.T) {
+	r := NewRegistry(0)
+	_ = r.Register(NewGauge("b", WithHelp("B.")))
+	_ = r.Register(NewCounterVec("a_total", []string{"x"}))
+
+	got := r.Descs()
+	want := []Desc{
+		{Name: "a_total", Type: TypeCounter, VariableLabels: []string{"x"}},
+		{Name: "b", Type: TypeGauge, Help: "B."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Descs() = %+v, want %+v", got, want)
+	}
+}
+
+// TestDesc_Exposition tests that exporters use descriptor metadata.
+func TestDesc_Exposition(t *testing.T) {
+	r := NewRegistry(0)
+	h := NewHistogram("request_duration_seconds", []float64{1},
+		WithHelp("Request duration."),
+		WithUnit("seconds"),
+		WithConstLabels(Labels{"service": "api"}),
+	)
+	h.Observe(0.5)
+	_ = r.Register(h)
+	_ = r.Register(NewGauge("queue_depth", WithUnit("seconds")))
+
+	t.Run("prometheus", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WritePrometheus(&buf, r); err != nil {
+			t.Fatalf("WritePrometheus() error = %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{
+			"# HELP request_duration_seconds Request duration.\n",
+			`request_duration_seconds_bucket{service="api",le="1"} 1` + "\n",
+			`request_duration_seconds_count{service="api"} 1` + "\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("openmetrics", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteOpenMetrics(&buf, r); err != nil {
+			t.Fatalf("WriteOpenMetrics() error = %v", err)
+		}
+
+		out := buf.String()
+		if !strings.Contains(out, "# UNIT request_duration_seconds seconds\n") {
+			t.Errorf("output missing # UNIT line:\n%s", out)
+		}
+		// A unit that is not the name suffix is not valid OpenMetrics.
+		if strings.Contains(out, "# UNIT queue_depth") {
+			t.Errorf("output has # UNIT for mismatched name:\n%s", out)
+		}
+	})
+}
+
+// TestRegistry_LabelNames tests that metrics and collectors with label
+// names that cannot be exposed are rejected.
+func TestRegistry_LabelNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		metric  Metric
+		wantErr bool
+	}{
+		{name: "valid", metric: NewCounterVec("requests_total", []string{"code", "_method2"}, WithConstLabels(Labels{"service": "api"}))},
+		{name: "dash", metric: NewCounterVec("requests_total", []string{"bad-name"}), wantErr: true},
+		{name: "colon", metric: NewCounterVec("requests_total", []string{"a:b"}), wantErr: true},
+		{name: "leading digit", metric: NewCounterVec("requests_total", []string{"1code"}), wantErr: true},
+		{name: "empty", metric: NewCounterVec("requests_total", []string{""}), wantErr: true},
+		{name: "reserved prefix", metric: NewCounterVec("requests_total", []string{"__name"}), wantErr: true},
+		{name: "repeated", metric: NewCounterVec("requests_total", []string{"code", "code"}), wantErr: true},
+		{name: "le on histogram vector", metric: NewHistogramVec("latency_seconds", []string{"le"}, nil), wantErr: true},
+		{name: "le on histogram", metric: NewHistogram("latency_seconds", nil, WithConstLabels(Labels{"le": "1"})), wantErr: true},
+		{name: "quantile on summary vector", metric: NewSummaryVec("rpc_seconds", []string{"quantile"}, SummaryConfig{}), wantErr: true},
+		{name: "le on counter", metric: NewCounterVec("requests_total", []string{"le"})},
+		{name: "invalid constant label", metric: NewGauge("temp", WithConstLabels(Labels{"bad name": "x"})), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRegistry(0).Register(tt.metric)
+			if tt.wantErr != errors.Is(err, ErrInvalidLabelName) {
+				t.Errorf("Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("collector descriptors", func(t *testing.T) {
+		c := descsCollector{{Name: "remote", Type: TypeGauge, ConstLabels: Labels{"bad-name": "x"}}}
+		if err := NewRegistry(0).RegisterCollector(c); !errors.Is(err, ErrInvalidLabelName) {
+			t.Errorf("RegisterCollector() error = %v, want ErrInvalidLabelName", err)
+		}
+	})
+}
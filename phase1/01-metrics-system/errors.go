@@ -11,7 +11,35 @@ var (
 	// a metric that does not exist in the registry.
 	ErrMetricNotFound = errors.New("metric not found")
 
-	// ErrInvalidMetricName is returned when attempting to register a metric
-	// with an empty or invalid name.
+	// ErrInvalidMetricName is wrapped by the *NameError returned when
+	// attempting to register a metric with an empty name or a name that
+	// violates the registry's NamingPolicy.
 	ErrInvalidMetricName = errors.New("metric name cannot be empty")
+
+	// ErrMetricTypeMismatch is returned when a metric of one type is
+	// requested under a name that is registered to a metric of another
+	// type.
+	ErrMetricTypeMismatch = errors.New("metric registered with a different type")
+
+	// ErrCollectorPanic is returned when a Collector panics during
+	// collection.
+	ErrCollectorPanic = errors.New("collector panicked")
+
+	// ErrReporterPanic is returned when a Reporter panics during a report
+	// of a ReportLoop.
+	ErrReporterPanic = errors.New("reporter panicked")
+
+	// ErrInvalidLabelName is returned when a metric is registered with a
+	// label name that cannot be exposed: one outside
+	// [a-zA-Z_][a-zA-Z0-9_]*, one starting with __, which is reserved, a
+	// repeated one, or the le or quantile label of a histogram or summary.
+	ErrInvalidLabelName = errors.New("invalid label name")
+
+	// ErrLabelMismatch is returned when the labels passed to a metric vector
+	// do not match the label names it was declared with.
+	ErrLabelMismatch = errors.New("labels do not match declared label names")
+
+	// ErrInvalidExemplar is returned when an exemplar's label set is empty
+	// or exceeds the OpenMetrics length limit.
+	ErrInvalidExemplar = errors.New("invalid exemplar")
 )
@@ -2,6 +2,7 @@ package metrics_test
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -39,6 +40,115 @@ func ExampleGauge() {
 	// Output: 20.5
 }
 
+// ExampleHistogram demonstrates basic histogram usage.
+func ExampleHistogram() {
+	latency := metrics.NewHistogram("request_duration_seconds",
+		metrics.LinearBuckets(0.1, 0.1, 3))
+
+	latency.Observe(0.05)
+	latency.Observe(0.15)
+	latency.Observe(0.25)
+	latency.Observe(1.2)
+
+	snapshot := latency.Snapshot()
+	for _, b := range snapshot.Buckets {
+		fmt.Printf("le=%.1f: %d\n", b.UpperBound, b.Count)
+	}
+	fmt.Printf("count=%d sum=%.2f\n", snapshot.Count, snapshot.Sum)
+	// Output:
+	// le=0.1: 1
+	// le=0.2: 2
+	// le=0.3: 3
+	// count=4 sum=1.65
+}
+
+// ExampleSummary demonstrates quantile estimation with a summary.
+func ExampleSummary() {
+	latency := metrics.NewSummary("rpc_duration_seconds", metrics.SummaryConfig{
+		Objectives: map[float64]float64{0.5: 0.05, 0.99: 0.001},
+		MaxAge:     time.Minute,
+	})
+
+	for i := 1; i <= 100; i++ {
+		latency.Observe(float64(i) / 100)
+	}
+
+	snapshot := latency.Snapshot()
+	for _, q := range snapshot.Quantiles {
+		fmt.Printf("p%.0f: %.2f\n", q.Quantile*100, q.Value)
+	}
+	fmt.Printf("count=%d\n", snapshot.Count)
+	// Output:
+	// p50: 0.50
+	// p99: 0.99
+	// count=100
+}
+
+// ExampleCounterVec demonstrates labeled counters.
+func ExampleCounterVec() {
+	registry := metrics.NewRegistry(10)
+	requests := metrics.NewCounterVec("http_requests_total", []string{"method", "status"})
+	registry.Register(requests)
+
+	if c, err := requests.WithLabelValues("GET", "200"); err == nil {
+		c.Add(10)
+	}
+	if c, err := requests.With(metrics.Labels{"method": "POST", "status": "500"}); err == nil {
+		c.Inc()
+	}
+
+	snapshot := registry.Snapshot()
+	fmt.Println(snapshot[`http_requests_total{method="GET",status="200"}`])
+	fmt.Println(snapshot[`http_requests_total{method="POST",status="500"}`])
+	// Output:
+	// 10
+	// 1
+}
+
+// ExampleWritePrometheus demonstrates the Prometheus text exposition format.
+func ExampleWritePrometheus() {
+	registry := metrics.NewRegistry(10)
+
+	requests := metrics.NewCounterVec("http_requests_total", []string{"code"})
+	registry.Register(requests)
+	if c, err := requests.WithLabelValues("200"); err == nil {
+		c.Add(42)
+	}
+
+	inFlight := metrics.NewGauge("http_requests_in_flight")
+	registry.Register(inFlight)
+	inFlight.Set(3)
+
+	if err := metrics.WritePrometheus(os.Stdout, registry); err != nil {
+		fmt.Println("Error:", err)
+	}
+	// Output:
+	// # TYPE http_requests_in_flight gauge
+	// http_requests_in_flight 3
+	// # TYPE http_requests_total counter
+	// http_requests_total{code="200"} 42
+}
+
+// ExampleTimer demonstrates timing a function into a histogram.
+func ExampleTimer() {
+	latency := metrics.NewHistogram("handler_duration_seconds", nil)
+
+	handle := func() {
+		timer := metrics.NewTimer(latency)
+		defer timer.ObserveDuration()
+
+		// Handle the request...
+	}
+	handle()
+
+	metrics.Time(latency, func() {
+		// Handle another request...
+	})
+
+	fmt.Println(latency.Snapshot().Count)
+	// Output: 2
+}
+
 // ExampleRegistry demonstrates registry usage.
 func ExampleRegistry() {
 	// Create a registry with capacity hint
@@ -259,6 +369,72 @@ func ExampleRegistry_Snapshot() {
 	// 3
 }
 
+// ExampleNewGaugeFunc demonstrates a gauge computed at collection time.
+func ExampleNewGaugeFunc() {
+	registry := metrics.NewRegistry(0)
+
+	queue := []string{"a", "b", "c"}
+	err := registry.RegisterCollector(metrics.NewGaugeFunc("queue_depth",
+		func() float64 { return float64(len(queue)) },
+		metrics.WithHelp("Jobs waiting to run."),
+	))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	metrics.WritePrometheus(os.Stdout, registry)
+	// Output:
+	// # HELP queue_depth Jobs waiting to run.
+	// # TYPE queue_depth gauge
+	// queue_depth 3
+}
+
+// ExampleScope demonstrates per-module namespaces with inherited tags.
+func ExampleScope() {
+	registry := metrics.NewRegistry(0)
+	root := metrics.NewScope(registry)
+
+	http := root.SubScope("http").Tagged(metrics.Labels{"service": "api"})
+	requests, err := http.Counter("requests_total")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	requests.Inc()
+
+	metrics.WritePrometheus(os.Stdout, registry)
+	// Output:
+	// # TYPE http_requests_total counter
+	// http_requests_total{service="api"} 1
+}
+
+// ExampleRegistry_Collect demonstrates reading typed samples.
+func ExampleRegistry_Collect() {
+	registry := metrics.NewRegistry(0)
+
+	requests := metrics.NewCounterVec("requests_total", []string{"method"})
+	get, _ := requests.WithLabelValues("GET")
+	get.Add(3)
+	registry.Register(requests)
+
+	temperature := metrics.NewGauge("temperature")
+	temperature.Set(21.5)
+	registry.Register(temperature)
+
+	samples, err := registry.Collect()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, s := range samples {
+		fmt.Printf("%s%s %v (%s)\n", s.Name, s.Labels, s.Value, s.Type)
+	}
+	// Output:
+	// requests_total{method="GET"} 3 (counter)
+	// temperature 21.5 (gauge)
+}
+
 // Benchmark examples
 
 // BenchmarkCounter_Inc benchmarks counter increment operations.
@@ -281,6 +457,38 @@ func BenchmarkCounter_Concurrent(b *testing.B) {
 	})
 }
 
+// BenchmarkShardedCounter_Inc benchmarks sharded counter increment
+// operations.
+func BenchmarkShardedCounter_Inc(b *testing.B) {
+	counter := metrics.NewShardedCounter("bench")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		counter.Inc()
+	}
+}
+
+// BenchmarkShardedCounter_Concurrent benchmarks concurrent sharded counter
+// operations. Compare it with BenchmarkCounter_Concurrent at several -cpu
+// values to see the scaling gain.
+func BenchmarkShardedCounter_Concurrent(b *testing.B) {
+	counter := metrics.NewShardedCounter("bench")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Inc()
+		}
+	})
+}
+
+// BenchmarkShardedCounter_Load benchmarks reading a sharded counter.
+func BenchmarkShardedCounter_Load(b *testing.B) {
+	counter := metrics.NewShardedCounter("bench")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = counter.Load()
+	}
+}
+
 // BenchmarkGauge_Set benchmarks gauge set operations.
 func BenchmarkGauge_Set(b *testing.B) {
 	gauge := metrics.NewGauge("bench")
@@ -290,6 +498,24 @@ func BenchmarkGauge_Set(b *testing.B) {
 	}
 }
 
+// BenchmarkHistogram_Observe benchmarks histogram observations.
+func BenchmarkHistogram_Observe(b *testing.B) {
+	histogram := metrics.NewHistogram("bench", nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		histogram.Observe(float64(i%100) / 10)
+	}
+}
+
+// BenchmarkSummary_Observe benchmarks summary observations.
+func BenchmarkSummary_Observe(b *testing.B) {
+	summary := metrics.NewSummary("bench", metrics.SummaryConfig{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		summary.Observe(float64(i % 1000))
+	}
+}
+
 // BenchmarkRegistry_Register benchmarks metric registration.
 func BenchmarkRegistry_Register(b *testing.B) {
 	b.ResetTimer()
@@ -317,6 +543,20 @@ func BenchmarkRegistry_Snapshot(b *testing.B) {
 	}
 }
 
+// BenchmarkRegistry_CollectRuntime benchmarks collecting Go runtime metrics.
+func BenchmarkRegistry_CollectRuntime(b *testing.B) {
+	registry := metrics.NewRegistry(0)
+	if err := metrics.RegisterRuntimeCollector(registry); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = registry.Collect()
+	}
+}
+
 // Example demonstrating real-world usage pattern
 func Example_realWorld() {
 	// Create a global registry (typically initialized at startup)
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// maxExemplarLabelRunes is the maximum combined length of an exemplar's
+// label names and values allowed by OpenMetrics.
+const maxExemplarLabelRunes = 128
+
+// Exemplar is a sample observation attached to a counter increment or a
+// histogram bucket, typically carrying a trace ID that links the metric to
+// a specific request.
+type Exemplar struct {
+	// Labels identify the exemplar, e.g. {"trace_id": "4bf92f35"}.
+	Labels Labels
+
+	// Value is the observed value: the increment for counters, the
+	// observation for histograms.
+	Value float64
+
+	// Timestamp is when the exemplar was recorded.
+	Timestamp time.Time
+}
+
+// newExemplar validates labels and returns an exemplar recorded now.
+// It returns ErrInvalidExemplar if the labels are empty or too long.
+func newExemplar(labels Labels, value float64) (*Exemplar, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("%w: no labels", ErrInvalidExemplar)
+	}
+
+	var runes int
+	for name, v := range labels {
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty label name", ErrInvalidExemplar)
+		}
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(v)
+	}
+	if runes > maxExemplarLabelRunes {
+		return nil, fmt.Errorf("%w: labels have %d runes, limit is %d",
+			ErrInvalidExemplar, runes, maxExemplarLabelRunes)
+	}
+
+	return &Exemplar{
+		Labels:    labels.clone(),
+		Value:     value,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// clone returns a deep copy of e, or nil if e is nil. Recorded exemplars
+// are never modified, so copies handed out cannot change them.
+func (e *Exemplar) clone() *Exemplar {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	c.Labels = e.Labels.clone()
+	return &c
+}
+
+// createdOf returns the creation time of a metric, or the zero time if the
+// metric does not track one.
+func createdOf(m Metric) time.Time {
+	if c, ok := m.(interface{ Created() time.Time }); ok {
+		return c.Created()
+	}
+	return time.Time{}
+}
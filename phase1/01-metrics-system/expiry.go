@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// DefaultSweepInterval is the default interval between expiry sweeps.
+const DefaultSweepInterval = time.Minute
+
+// updateTracker records whether a metric was updated since the last expiry
+// sweep. It is embedded in the built-in metric types and costs a single
+// atomic load per update once set.
+type updateTracker struct {
+	touched atomic.Bool
+}
+
+// touch marks the metric as updated.
+func (t *updateTracker) touch() {
+	// Load first so hot metrics don't write the shared cache line on
+	// every update.
+	if !t.touched.Load() {
+		t.touched.Store(true)
+	}
+}
+
+// swapTouched reports whether the metric was updated since the last call
+// and clears the mark.
+func (t *updateTracker) swapTouched() bool {
+	return t.touched.Swap(false)
+}
+
+// expirable is implemented by metrics that track updates for expiry.
+type expirable interface {
+	swapTouched() bool
+}
+
+// ExpirerConfig configures an Expirer. The zero value selects the defaults.
+type ExpirerConfig struct {
+	// TTL is the default time-to-live for metrics without a TTL of their
+	// own. A metric not updated within its TTL is evicted. Zero means
+	// metrics without their own TTL never expire.
+	TTL time.Duration
+
+	// Interval is the time between sweeps. It bounds how long past its TTL
+	// a stale metric may linger. Defaults to DefaultSweepInterval.
+	Interval time.Duration
+
+	// OnEvict, if set, is called for every evicted metric with its name and
+	// labels. It is called synchronously from the sweep.
+	OnEvict func(name string, labels Labels)
+
+	// Clock is the time source used to age metrics.
+	// Defaults to the system clock.
+	Clock Clock
+}
+
+// Expirer evicts metrics that have not been updated within their TTL from
+// a Registry. Children of metric vectors are evicted individually; other
+// metrics are unregistered. Only metrics that track updates expire, which
+// includes Counter, Gauge, Histogram, Summary and their vectors.
+//
+// Update times are tracked with sweep granularity: a metric's age is
+// measured from the first sweep that observed it unchanged. Code still
+// holding a reference to an evicted metric can keep updating it, but the
+// updates are no longer visible through the registry.
+//
+// An Expirer is safe for concurrent use.
+type Expirer struct {
+	registry  *Registry
+	cfg       ExpirerConfig
+	clock     Clock
+	evictions *Counter
+
+	mu       sync.Mutex
+	ttls     map[string]time.Duration
+	lastSeen map[Metric]time.Time
+}
+
+// NewExpirer creates an expirer for r. Call Run to start sweeping in the
+// background, or Sweep to sweep once.
+func NewExpirer(r *Registry, cfg ExpirerConfig) *Expirer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultSweepInterval
+	}
+	return &Expirer{
+		registry:  r,
+		cfg:       cfg,
+		clock:     clockOrDefault(cfg.Clock),
+		evictions: NewCounter("metrics_evicted_total"),
+		ttls:      make(map[string]time.Duration),
+		lastSeen:  make(map[Metric]time.Time),
+	}
+}
+
+// SetTTL sets the time-to-live of the metric with the given name,
+// overriding the default. A zero ttl disables expiry for that metric.
+func (e *Expirer) SetTTL(name string, ttl time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.ttls[name] = ttl
+}
+
+// Evictions returns the counter of evicted metrics. It is not registered
+// anywhere; register it in a Registry to expose it.
+func (e *Expirer) Evictions() *Counter {
+	return e.evictions
+}
+
+// Run sweeps the registry every interval until ctx is cancelled.
+func (e *Expirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Sweep()
+		}
+	}
+}
+
+// Sweep evicts every metric that has not been updated within its TTL and
+// returns the number of evicted metrics.
+func (e *Expirer) Sweep() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	seen := make(map[Metric]struct{}, len(e.lastSeen))
+	evicted := 0
+
+	for _, metric := range e.registry.load().sortedMetrics() {
+		name := metric.Name()
+		ttl, ok := e.ttls[name]
+		if !ok {
+			ttl = e.cfg.TTL
+		}
+		if ttl <= 0 {
+			continue
+		}
+
+		family, isFamily := metric.(metricFamily)
+		if !isFamily {
+			seen[metric] = struct{}{}
+			if e.expiredLocked(metric, now, ttl) && e.registry.unregisterIf(name, metric) {
+				e.evictLocked(metric)
+				evicted++
+			}
+			continue
+		}
+
+		deleter, ok := metric.(interface{ Delete(Labels) bool })
+		if !ok {
+			continue
+		}
+		for _, child := range family.children() {
+			seen[child] = struct{}{}
+			if e.expiredLocked(child, now, ttl) && deleter.Delete(labelsOf(child)) {
+				e.evictLocked(child)
+				evicted++
+			}
+		}
+	}
+
+	// Forget metrics that were removed by other means.
+	for metric := range e.lastSeen {
+		if _, ok := seen[metric]; !ok {
+			delete(e.lastSeen, metric)
+		}
+	}
+
+	return evicted
+}
+
+// expiredLocked reports whether m has gone without updates for at least
+// ttl. e.mu must be held.
+func (e *Expirer) expiredLocked(m Metric, now time.Time, ttl time.Duration) bool {
+	tracked, ok := m.(expirable)
+	if !ok {
+		return false
+	}
+
+	last, ok := e.lastSeen[m]
+	if tracked.swapTouched() || !ok {
+		e.lastSeen[m] = now
+		return false
+	}
+	return now.Sub(last) >= ttl
+}
+
+// evictLocked records the eviction of m. e.mu must be held.
+func (e *Expirer) evictLocked(m Metric) {
+	delete(e.lastSeen, m)
+	e.evictions.Inc()
+	if e.cfg.OnEvict != nil {
+		e.cfg.OnEvict(m.Name(), labelsOf(m))
+	}
+}
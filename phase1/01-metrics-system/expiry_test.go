@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestExpirer tests eviction of stale metrics.
+func TestExpirer(t *testing.T) {
+	t.Run("evicts metrics not updated within TTL", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		stale := NewGauge("stale")
+		fresh := NewCounter("fresh")
+		_ = r.Register(stale)
+		_ = r.Register(fresh)
+
+		var evicted []string
+		e := NewExpirer(r, ExpirerConfig{
+			TTL:   time.Minute,
+			Clock: clock,
+			OnEvict: func(name string, labels Labels) {
+				evicted = append(evicted, name)
+			},
+		})
+
+		// The first sweep starts the clock for every metric.
+		if got := e.Sweep(); got != 0 {
+			t.Errorf("first Sweep() evicted %d metrics, want 0", got)
+		}
+
+		clock.Advance(45 * time.Second)
+		fresh.Inc()
+		if got := e.Sweep(); got != 0 {
+			t.Errorf("Sweep() before TTL evicted %d metrics, want 0", got)
+		}
+
+		clock.Advance(30 * time.Second)
+		if got := e.Sweep(); got != 1 {
+			t.Errorf("Sweep() after TTL evicted %d metrics, want 1", got)
+		}
+
+		if _, ok := r.Get("stale"); ok {
+			t.Error("stale metric still registered after expiry")
+		}
+		if _, ok := r.Get("fresh"); !ok {
+			t.Error("recently updated metric was evicted")
+		}
+		if !reflect.DeepEqual(evicted, []string{"stale"}) {
+			t.Errorf("OnEvict called with %v, want [stale]", evicted)
+		}
+		if got := e.Evictions().Load(); got != 1 {
+			t.Errorf("Evictions() = %v, want 1", got)
+		}
+	})
+
+	t.Run("evicts vector children individually", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		v := NewGaugeVec("customer_balance", []string{"customer"})
+		_ = r.Register(v)
+
+		active, _ := v.WithLabelValues("active")
+		idle, _ := v.WithLabelValues("idle")
+		active.Set(1)
+		idle.Set(1)
+
+		var evictedLabels []Labels
+		e := NewExpirer(r, ExpirerConfig{
+			TTL:   time.Minute,
+			Clock: clock,
+			OnEvict: func(name string, labels Labels) {
+				evictedLabels = append(evictedLabels, labels)
+			},
+		})
+		e.Sweep()
+
+		clock.Advance(2 * time.Minute)
+		active.Inc()
+		active.Inc()
+		if got := e.Sweep(); got != 1 {
+			t.Fatalf("Sweep() evicted %d children, want 1", got)
+		}
+
+		want := map[string]interface{}{`customer_balance{customer="active"}`: 3.0}
+		if got := r.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Snapshot() = %v, want %v", got, want)
+		}
+		if !reflect.DeepEqual(evictedLabels, []Labels{{"customer": "idle"}}) {
+			t.Errorf("OnEvict labels = %v, want [{customer: idle}]", evictedLabels)
+		}
+	})
+
+	t.Run("per-metric TTL overrides default", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("short"))
+		_ = r.Register(NewGauge("forever"))
+		_ = r.Register(NewGauge("default"))
+
+		e := NewExpirer(r, ExpirerConfig{TTL: time.Hour, Clock: clock})
+		e.SetTTL("short", time.Second)
+		e.SetTTL("forever", 0)
+		e.Sweep()
+
+		clock.Advance(time.Minute)
+		if got := e.Sweep(); got != 1 {
+			t.Errorf("Sweep() evicted %d metrics, want 1", got)
+		}
+		if _, ok := r.Get("short"); ok {
+			t.Error("metric with short TTL was not evicted")
+		}
+
+		clock.Advance(2 * time.Hour)
+		e.Sweep()
+		if _, ok := r.Get("forever"); !ok {
+			t.Error("metric with disabled TTL was evicted")
+		}
+		if _, ok := r.Get("default"); ok {
+			t.Error("metric with default TTL was not evicted")
+		}
+	})
+
+	t.Run("does not evict re-registered replacement", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		old := NewGauge("g")
+		_ = r.Register(old)
+
+		e := NewExpirer(r, ExpirerConfig{TTL: time.Minute, Clock: clock})
+		e.Sweep()
+
+		_ = r.Unregister("g")
+		replacement := NewGauge("g")
+		_ = r.Register(replacement)
+
+		clock.Advance(2 * time.Minute)
+		if got := e.Sweep(); got != 0 {
+			t.Errorf("Sweep() evicted %d metrics, want 0 for new registration", got)
+		}
+		if m, _ := r.Get("g"); m != replacement {
+			t.Error("replacement metric was evicted")
+		}
+	})
+
+	t.Run("never expires untracked metrics", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		_ = r.Register(unsupportedMetric{})
+
+		e := NewExpirer(r, ExpirerConfig{TTL: time.Second, Clock: clock})
+		e.Sweep()
+		clock.Advance(time.Hour)
+
+		if got := e.Sweep(); got != 0 {
+			t.Errorf("Sweep() evicted %d untracked metrics, want 0", got)
+		}
+	})
+}
+
+// TestExpirer_Run tests that the background sweeper stops on cancellation.
+func TestExpirer_Run(t *testing.T) {
+	r := NewRegistry(0)
+	e := NewExpirer(r, ExpirerConfig{TTL: time.Nanosecond, Interval: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+
+	_ = r.Register(NewGauge("g"))
+	deadline := time.After(5 * time.Second)
+	for r.Len() != 0 {
+		select {
+		case <-deadline:
+			t.Fatal("background sweeper did not evict stale metric")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after context cancellation")
+	}
+}
@@ -5,18 +5,24 @@ import "go.uber.org/atomic"
 // Gauge is a metric that can increase or decrease and is safe for
 // concurrent use by multiple goroutines. The zero value is ready to use.
 type Gauge struct {
-	name  string
-	value atomic.Float64
+	name   string
+	desc   *Desc
+	labels Labels
+	value  atomic.Float64
+	updateTracker
 }
 
 // Compile-time verification that Gauge implements Metric interface.
 var _ Metric = (*Gauge)(nil)
 
-// NewGauge creates a new gauge metric with the given name.
+// NewGauge creates a new gauge metric with the given name and options.
 // The gauge starts at 0.0 and can be set to any value.
-func NewGauge(name string) *Gauge {
+func NewGauge(name string, opts ...Option) *Gauge {
+	desc := newDesc(name, TypeGauge, nil, opts)
 	return &Gauge{
-		name: name,
+		name:   name,
+		desc:   &desc,
+		labels: desc.ConstLabels,
 	}
 }
 
@@ -25,6 +31,17 @@ func (g *Gauge) Name() string {
 	return g.name
 }
 
+// Desc returns the descriptor of this gauge.
+func (g *Gauge) Desc() Desc {
+	return describe(g.name, TypeGauge, g.desc)
+}
+
+// Labels returns a copy of the labels of this gauge: its constant labels
+// and, for children of a GaugeVec, its variable labels.
+func (g *Gauge) Labels() Labels {
+	return g.labels.clone()
+}
+
 // Type returns TypeGauge, indicating this is a gauge metric.
 func (g *Gauge) Type() MetricType {
 	return TypeGauge
@@ -40,18 +57,21 @@ func (g *Gauge) Value() interface{} {
 // This operation is atomic and safe for concurrent use.
 func (g *Gauge) Set(value float64) {
 	g.value.Store(value)
+	g.touch()
 }
 
 // Inc increments the gauge by 1.
 // This operation is atomic and safe for concurrent use.
 func (g *Gauge) Inc() {
 	g.value.Add(1.0)
+	g.touch()
 }
 
 // Dec decrements the gauge by 1.
 // This operation is atomic and safe for concurrent use.
 func (g *Gauge) Dec() {
 	g.value.Add(-1.0)
+	g.touch()
 }
 
 // Add adds the given delta to the gauge.
@@ -59,6 +79,7 @@ func (g *Gauge) Dec() {
 // This operation is atomic and safe for concurrent use.
 func (g *Gauge) Add(delta float64) {
 	g.value.Add(delta)
+	g.touch()
 }
 
 // Load returns the current value of the gauge.
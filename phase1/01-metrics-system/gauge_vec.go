@@ -0,0 +1,78 @@
+package metrics
+
+// GaugeVec is a family of gauges that share a name and are
+// distinguished by label values, e.g. queue_depth{queue="jobs"}.
+// Children are created on first use and cached. It is safe for concurrent
+// use by multiple goroutines.
+type GaugeVec struct {
+	vec *metricVec[*Gauge]
+}
+
+// Compile-time verification that GaugeVec implements metricFamily.
+var _ metricFamily = (*GaugeVec)(nil)
+
+// NewGaugeVec creates a new gauge vector with the given name, label names
+// and options.
+func NewGaugeVec(name string, labelNames []string, opts ...Option) *GaugeVec {
+	desc := newDesc(name, TypeGauge, labelNames, opts)
+	return &GaugeVec{
+		vec: newMetricVec(desc, func(labels Labels) *Gauge {
+			return &Gauge{name: name, desc: &desc, labels: labels}
+		}),
+	}
+}
+
+// Name returns the name shared by all gauges in this vector.
+func (v *GaugeVec) Name() string {
+	return v.vec.desc.Name
+}
+
+// Desc returns the descriptor shared by all gauges in this vector.
+func (v *GaugeVec) Desc() Desc {
+	return v.vec.desc.clone()
+}
+
+// Type returns TypeGauge, the type of every child in this vector.
+func (v *GaugeVec) Type() MetricType {
+	return TypeGauge
+}
+
+// Value returns the current values of all children as an interface{}.
+// The underlying type is map[string]interface{}, keyed by the children's
+// label sets as rendered by Labels.String, with float64 values.
+func (v *GaugeVec) Value() interface{} {
+	return v.vec.value()
+}
+
+// LabelNames returns a copy of the label names declared for this vector.
+func (v *GaugeVec) LabelNames() []string {
+	return append([]string(nil), v.vec.labelNames...)
+}
+
+// WithLabelValues returns the gauge for the given label values, given in
+// the order of the declared label names, creating it if needed.
+// It returns ErrLabelMismatch if the number of values is wrong.
+func (v *GaugeVec) WithLabelValues(values ...string) (*Gauge, error) {
+	return v.vec.withLabelValues(values)
+}
+
+// With returns the gauge for the given labels, creating it if needed.
+// It returns ErrLabelMismatch if the labels differ from the declared names.
+func (v *GaugeVec) With(labels Labels) (*Gauge, error) {
+	return v.vec.with(labels)
+}
+
+// Delete removes the gauge for the given labels.
+// It reports whether a gauge was removed.
+func (v *GaugeVec) Delete(labels Labels) bool {
+	return v.vec.delete(labels)
+}
+
+// Reset removes all gauges from this vector.
+func (v *GaugeVec) Reset() {
+	v.vec.reset()
+}
+
+func (v *GaugeVec) children() []Metric {
+	return v.vec.children()
+}
@@ -0,0 +1,350 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGraphiteInterval is the default interval between Graphite
+	// flushes.
+	DefaultGraphiteInterval = time.Minute
+
+	// DefaultGraphiteBufferSize is the default number of lines buffered
+	// while the Graphite connection is down.
+	DefaultGraphiteBufferSize = 10000
+
+	// DefaultGraphiteMinBackoff is the default delay before the first
+	// reconnection attempt.
+	DefaultGraphiteMinBackoff = time.Second
+
+	// DefaultGraphiteMaxBackoff is the default upper bound of the
+	// reconnection delay.
+	DefaultGraphiteMaxBackoff = time.Minute
+
+	// DefaultGraphiteTimeout is the default timeout for dialing and
+	// writing.
+	DefaultGraphiteTimeout = 10 * time.Second
+)
+
+// GraphiteConfig configures a GraphiteReporter. Address is required; the
+// other fields default when zero.
+type GraphiteConfig struct {
+	// Address is the host:port of the Carbon plaintext listener.
+	Address string
+
+	// Prefix is prepended to every metric path, separated by a dot.
+	Prefix string
+
+	// Interval is the time between flushes when running with Run.
+	// Defaults to DefaultGraphiteInterval.
+	Interval time.Duration
+
+	// BufferSize bounds the number of lines kept while disconnected. When
+	// full, the oldest lines are dropped. Defaults to
+	// DefaultGraphiteBufferSize.
+	BufferSize int
+
+	// MinBackoff and MaxBackoff bound the delay between reconnection
+	// attempts, which doubles after every failure. They default to
+	// DefaultGraphiteMinBackoff and DefaultGraphiteMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Timeout bounds dialing and each write.
+	// Defaults to DefaultGraphiteTimeout.
+	Timeout time.Duration
+
+	// Clock is the time source for line timestamps and backoff.
+	// Defaults to the system clock.
+	Clock Clock
+}
+
+// GraphiteReporter is a Reporter that sends metrics to a Graphite Carbon
+// server using the plaintext protocol, one "path value timestamp" line per
+// value over TCP.
+//
+// Metric names and label values become dotted paths, e.g.
+// prefix.http_requests.method.GET. Histograms and summaries are sent as
+// count, sum and per-bucket or per-quantile paths, and meters as count and
+// rate paths.
+//
+// Lines are buffered while the connection is down and the reporter
+// reconnects with exponential backoff. Carbon keeps the last value written
+// for a path and timestamp, so lines resent after a partial write are
+// harmless.
+//
+// It can be used as a sink of a ReportLoop, or report its own registry
+// with Flush and Run.
+//
+// A GraphiteReporter is safe for concurrent use.
+type GraphiteReporter struct {
+	registry *Registry
+	cfg      GraphiteConfig
+	clock    Clock
+	dial     func(network, address string, timeout time.Duration) (net.Conn, error)
+
+	mu          sync.Mutex
+	conn        net.Conn
+	pending     []string
+	backoff     time.Duration
+	nextAttempt time.Time
+	closed      bool
+}
+
+// Compile-time verification that GraphiteReporter implements Reporter.
+var _ Reporter = (*GraphiteReporter)(nil)
+
+// NewGraphiteReporter creates a reporter that sends the metrics of r to the
+// Carbon server at cfg.Address. The connection is established lazily on
+// the first flush.
+func NewGraphiteReporter(r *Registry, cfg GraphiteConfig) (*GraphiteReporter, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("graphite: address is required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultGraphiteInterval
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultGraphiteBufferSize
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = DefaultGraphiteMinBackoff
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = max(DefaultGraphiteMaxBackoff, cfg.MinBackoff)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultGraphiteTimeout
+	}
+
+	return &GraphiteReporter{
+		registry: r,
+		cfg:      cfg,
+		clock:    clockOrDefault(cfg.Clock),
+		dial:     net.DialTimeout,
+	}, nil
+}
+
+// Run flushes every interval until ctx is cancelled, using a ReportLoop.
+// Flush errors are dropped; undelivered lines stay buffered for the next
+// flush. It returns an error if the loop cannot be created, and nil once
+// ctx is cancelled.
+func (g *GraphiteReporter) Run(ctx context.Context) error {
+	loop, err := NewReportLoop(g.registry, ReportLoopConfig{
+		Interval: g.cfg.Interval,
+		Sinks:    []ReportSink{{Name: "graphite", Reporter: g, Timeout: g.cfg.Timeout}},
+	})
+	if err != nil {
+		return fmt.Errorf("graphite: %w", err)
+	}
+	loop.Run(ctx)
+	return nil
+}
+
+// Report renders samples and sends them together with any lines buffered
+// from earlier failed reports. If the connection cannot be used before ctx
+// is done, the lines stay buffered and an error is returned.
+func (g *GraphiteReporter) Report(ctx context.Context, samples []Sample) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return errors.New("graphite: reporter is closed")
+	}
+	return g.reportLocked(ctx, samples)
+}
+
+// Flush renders the current metric values of the registry and sends them
+// like Report.
+func (g *GraphiteReporter) Flush() error {
+	// Metrics that cannot be collected are skipped.
+	samples, _ := g.registry.Collect()
+	return g.Report(context.Background(), samples)
+}
+
+// Close flushes any pending lines and closes the connection.
+func (g *GraphiteReporter) Close() error {
+	samples, _ := g.registry.Collect()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return nil
+	}
+	g.closed = true
+
+	err := g.reportLocked(context.Background(), samples)
+	if g.conn != nil {
+		err = errors.Join(err, g.conn.Close())
+		g.conn = nil
+	}
+	return err
+}
+
+// reportLocked buffers the lines of samples and tries to send the buffer.
+// g.mu must be held.
+func (g *GraphiteReporter) reportLocked(ctx context.Context, samples []Sample) error {
+	now := g.clock.Now()
+	g.bufferLocked(g.lines(samples, now))
+
+	// Dialing and writing are bounded by both the timeout and ctx.
+	deadline := time.Now().Add(g.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if g.conn == nil {
+		if now.Before(g.nextAttempt) {
+			return fmt.Errorf("graphite: disconnected, next attempt in %v", g.nextAttempt.Sub(now))
+		}
+		conn, err := g.dial("tcp", g.cfg.Address, time.Until(deadline))
+		if err != nil {
+			g.backoffLocked(now)
+			return fmt.Errorf("graphite: dial %s: %w", g.cfg.Address, err)
+		}
+		g.conn = conn
+	}
+
+	if len(g.pending) == 0 {
+		return nil
+	}
+
+	payload := strings.Join(g.pending, "\n") + "\n"
+	_ = g.conn.SetWriteDeadline(deadline)
+	if _, err := g.conn.Write([]byte(payload)); err != nil {
+		g.conn.Close()
+		g.conn = nil
+		g.backoffLocked(now)
+		return fmt.Errorf("graphite: write: %w", err)
+	}
+
+	g.pending = g.pending[:0]
+	g.backoff = 0
+	return nil
+}
+
+// bufferLocked appends lines to the pending buffer, dropping the oldest
+// lines beyond the buffer size. g.mu must be held.
+func (g *GraphiteReporter) bufferLocked(lines []string) {
+	g.pending = append(g.pending, lines...)
+	if over := len(g.pending) - g.cfg.BufferSize; over > 0 {
+		g.pending = append(g.pending[:0], g.pending[over:]...)
+	}
+}
+
+// backoffLocked schedules the next connection attempt after a failure.
+// g.mu must be held.
+func (g *GraphiteReporter) backoffLocked(now time.Time) {
+	if g.backoff == 0 {
+		g.backoff = g.cfg.MinBackoff
+	} else {
+		g.backoff = min(2*g.backoff, g.cfg.MaxBackoff)
+	}
+	g.nextAttempt = now.Add(g.backoff)
+}
+
+// lines renders every value of samples as a plaintext protocol line.
+func (g *GraphiteReporter) lines(samples []Sample, now time.Time) []string {
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	var lines []string
+	add := func(path string, value float64) {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return
+		}
+		lines = append(lines, path+" "+strconv.FormatFloat(value, 'f', -1, 64)+" "+ts)
+	}
+
+	for _, s := range samples {
+		path := g.path(s.Name, s.Labels)
+
+		switch {
+		case s.Histogram != nil:
+			h := s.Histogram
+			add(path+".count", float64(h.Count))
+			add(path+".sum", h.Sum)
+			for _, b := range h.Buckets {
+				add(path+".bucket.le_"+sanitizeGraphite(formatFloat(b.UpperBound)), float64(b.Count))
+			}
+			add(path+".bucket.le_inf", float64(h.Count))
+		case s.Summary != nil:
+			sum := s.Summary
+			add(path+".count", float64(sum.Count))
+			add(path+".sum", sum.Sum)
+			for _, q := range sum.Quantiles {
+				add(path+".p"+percentileSegment(q.Quantile), q.Value)
+			}
+		case s.Meter != nil:
+			m := s.Meter
+			add(path+".count", float64(m.Count))
+			add(path+".rate_1m", m.Rate1)
+			add(path+".rate_5m", m.Rate5)
+			add(path+".rate_15m", m.Rate15)
+			add(path+".mean_rate", m.MeanRate)
+		default:
+			add(path, s.Value)
+		}
+	}
+
+	return lines
+}
+
+// path builds the dotted Graphite path for a metric.
+func (g *GraphiteReporter) path(name string, labels Labels) string {
+	var b strings.Builder
+	if g.cfg.Prefix != "" {
+		b.WriteString(g.cfg.Prefix)
+		b.WriteByte('.')
+	}
+	b.WriteString(sanitizeGraphite(name))
+	for _, k := range labels.names() {
+		b.WriteByte('.')
+		b.WriteString(sanitizeGraphite(k))
+		b.WriteByte('.')
+		b.WriteString(sanitizeGraphite(labels[k]))
+	}
+	return b.String()
+}
+
+// sanitizeGraphite makes s safe for use as a single Graphite path segment
+// by replacing every character other than letters, digits, '_' and '-'
+// with '_'.
+// percentileSegment returns the path segment of a quantile as a
+// percentile: 0.5 is 50, 0.999 is 99_9. It shifts the decimal digits of the
+// quantile rather than multiplying by 100, which would expose float
+// rounding errors such as 28.999999999999996 for 0.29.
+func percentileSegment(q float64) string {
+	digits, ok := strings.CutPrefix(strconv.FormatFloat(q, 'f', -1, 64), "0.")
+	if !ok {
+		// Quantiles outside (0, 1) are not tracked by summaries.
+		return sanitizeGraphite(formatFloat(q * 100))
+	}
+	if len(digits) < 2 {
+		digits += "0"
+	}
+	whole, fraction := strings.TrimPrefix(digits[:2], "0"), digits[2:]
+	if fraction == "" {
+		return whole
+	}
+	return whole + "_" + fraction
+}
+
+func sanitizeGraphite(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
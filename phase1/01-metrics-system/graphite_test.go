@@ -0,0 +1,282 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tcpListener is a local stand-in for a Carbon plaintext listener.
+type tcpListener struct {
+	t        *testing.T
+	listener net.Listener
+	lines    chan string
+}
+
+func newTCPListener(t *testing.T) *tcpListener {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	l := &tcpListener{t: t, listener: ln, lines: make(chan string, 1024)}
+	go l.serve()
+	return l
+}
+
+func (l *tcpListener) serve() {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				l.lines <- scanner.Text()
+			}
+		}()
+	}
+}
+
+func (l *tcpListener) addr() string {
+	return l.listener.Addr().String()
+}
+
+// read waits for n lines.
+func (l *tcpListener) read(n int) []string {
+	l.t.Helper()
+
+	var lines []string
+	for len(lines) < n {
+		select {
+		case line := <-l.lines:
+			lines = append(lines, line)
+		case <-time.After(5 * time.Second):
+			l.t.Fatalf("received %d lines %v, want %d", len(lines), lines, n)
+		}
+	}
+	return lines
+}
+
+func newTestGraphiteReporter(t *testing.T, r *Registry, cfg GraphiteConfig) *GraphiteReporter {
+	t.Helper()
+
+	g, err := NewGraphiteReporter(r, cfg)
+	if err != nil {
+		t.Fatalf("NewGraphiteReporter() error = %v", err)
+	}
+	t.Cleanup(func() { g.Close() })
+	return g
+}
+
+// TestGraphiteReporter tests the Graphite plaintext output.
+func TestGraphiteReporter(t *testing.T) {
+	t.Run("sends dotted paths with timestamps", func(t *testing.T) {
+		l := newTCPListener(t)
+		clock := newFakeClock()
+		// A dotted name exercises path sanitization.
+		r := NewRegistry(0)
+
+		v := NewCounterVec("http_requests", []string{"method", "path"})
+		c, _ := v.WithLabelValues("GET", "/users/{id}")
+		c.Add(3)
+		_ = r.Register(v)
+
+		g := NewGauge("cpu.temp")
+		g.Set(65.5)
+		_ = r.Register(g)
+
+		h := NewHistogram("latency", []float64{0.25})
+		h.Observe(0.1)
+		_ = r.Register(h)
+
+		s := NewSummary("rpc", SummaryConfig{Objectives: map[float64]float64{0.99: 0.001}})
+		s.Observe(2)
+		_ = r.Register(s)
+
+		reporter := newTestGraphiteReporter(t, r, GraphiteConfig{Address: l.addr(), Prefix: "svc.api", Clock: clock})
+		if err := reporter.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+
+		ts := " 1704067200"
+		want := []string{
+			"svc.api.cpu_temp 65.5" + ts,
+			"svc.api.http_requests.method.GET.path._users__id_ 3" + ts,
+			"svc.api.latency.count 1" + ts,
+			"svc.api.latency.sum 0.1" + ts,
+			"svc.api.latency.bucket.le_0_25 1" + ts,
+			"svc.api.latency.bucket.le_inf 1" + ts,
+			"svc.api.rpc.count 1" + ts,
+			"svc.api.rpc.sum 2" + ts,
+			"svc.api.rpc.p99 2" + ts,
+		}
+		if got := l.read(len(want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("lines = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("buffers while disconnected and reconnects with backoff", func(t *testing.T) {
+		l := newTCPListener(t)
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		c := NewCounter("jobs")
+		_ = r.Register(c)
+
+		reporter := newTestGraphiteReporter(t, r, GraphiteConfig{
+			Address:    l.addr(),
+			Clock:      clock,
+			MinBackoff: time.Second,
+			MaxBackoff: 4 * time.Second,
+		})
+
+		dials := 0
+		down := true
+		reporter.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+			dials++
+			if down {
+				return nil, errors.New("connection refused")
+			}
+			return net.DialTimeout(network, address, timeout)
+		}
+
+		c.Inc()
+		if err := reporter.Flush(); err == nil {
+			t.Fatal("Flush() while down should return error")
+		}
+
+		// Within the backoff window no connection is attempted.
+		clock.Advance(500 * time.Millisecond)
+		c.Inc()
+		if err := reporter.Flush(); err == nil {
+			t.Fatal("Flush() during backoff should return error")
+		}
+		if dials != 1 {
+			t.Errorf("dial attempts during backoff = %d, want 1", dials)
+		}
+
+		// After the backoff the reconnection succeeds and the buffer drains.
+		down = false
+		clock.Advance(time.Second)
+		c.Inc()
+		if err := reporter.Flush(); err != nil {
+			t.Fatalf("Flush() after backoff error = %v", err)
+		}
+
+		want := []string{"jobs 1 1704067200", "jobs 2 1704067200", "jobs 3 1704067201"}
+		if got := l.read(len(want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("lines = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("backoff doubles up to the maximum", func(t *testing.T) {
+		clock := newFakeClock()
+		reporter := newTestGraphiteReporter(t, NewRegistry(0), GraphiteConfig{
+			Address:    "127.0.0.1:1",
+			Clock:      clock,
+			MinBackoff: time.Second,
+			MaxBackoff: 3 * time.Second,
+		})
+		reporter.dial = func(string, string, time.Duration) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}
+
+		var got []time.Duration
+		for i := 0; i < 4; i++ {
+			_ = reporter.Flush()
+			got = append(got, reporter.backoff)
+			clock.Advance(reporter.backoff)
+		}
+
+		want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("backoff sequence = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("drops oldest lines beyond buffer size", func(t *testing.T) {
+		l := newTCPListener(t)
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		c := NewCounter("jobs")
+		_ = r.Register(c)
+
+		reporter := newTestGraphiteReporter(t, r, GraphiteConfig{
+			Address:    l.addr(),
+			Clock:      clock,
+			BufferSize: 2,
+			MinBackoff: time.Nanosecond,
+		})
+		down := true
+		reporter.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+			if down {
+				return nil, errors.New("connection refused")
+			}
+			return net.DialTimeout(network, address, timeout)
+		}
+
+		for i := 0; i < 3; i++ {
+			c.Inc()
+			clock.Advance(time.Second)
+			_ = reporter.Flush()
+		}
+
+		down = false
+		c.Inc()
+		clock.Advance(time.Second)
+		if err := reporter.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+
+		want := []string{"jobs 3 1704067203", "jobs 4 1704067204"}
+		if got := l.read(len(want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("lines = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("requires address", func(t *testing.T) {
+		if _, err := NewGraphiteReporter(NewRegistry(0), GraphiteConfig{}); err == nil {
+			t.Error("NewGraphiteReporter() without address should return error")
+		}
+	})
+
+	t.Run("Flush after Close returns error", func(t *testing.T) {
+		reporter, _ := NewGraphiteReporter(NewRegistry(0), GraphiteConfig{Address: newTCPListener(t).addr()})
+		if err := reporter.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if err := reporter.Flush(); err == nil {
+			t.Error("Flush() after Close() should return error")
+		}
+	})
+}
+
+// TestPercentileSegment tests the path segments of summary quantiles.
+func TestPercentileSegment(t *testing.T) {
+	tests := []struct {
+		quantile float64
+		want     string
+	}{
+		{0.5, "50"},
+		{0.99, "99"},
+		{0.999, "99_9"},
+		{0.05, "5"},
+		{0.29, "29"},
+		{0.57, "57"},
+		{0.001, "0_1"},
+	}
+
+	for _, tt := range tests {
+		if got := percentileSegment(tt.quantile); got != tt.want {
+			t.Errorf("percentileSegment(%v) = %q, want %q", tt.quantile, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package metrics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandlerOpts configures a Handler. The zero value selects the defaults.
+type HandlerOpts struct {
+	// Timeout bounds how long a single scrape may take to encode. Scrapes
+	// exceeding it fail with 503 Service Unavailable, and their collectors
+	// are cancelled. Zero means no timeout.
+	Timeout time.Duration
+
+	// MaxInFlight caps the number of scrapes encoded concurrently. Scrapes
+	// beyond the cap fail immediately with 503 Service Unavailable.
+	// Zero means no limit.
+	MaxInFlight int
+
+	// DisableCompression disables gzip compression of responses.
+	DisableCompression bool
+}
+
+// Scrape error causes recorded by a Handler's error counter.
+const (
+	scrapeErrorCollect = "collect"
+	scrapeErrorEncode  = "encode"
+	scrapeErrorTimeout = "timeout"
+	scrapeErrorLimit   = "limit"
+	scrapeErrorWrite   = "write"
+)
+
+// Handler is an http.Handler that serves the metrics of a Registry.
+// It negotiates the format from the Accept header, choosing between the
+// Prometheus text format, OpenMetrics and JSON, and compresses responses
+// with gzip when the client accepts it. Metrics that cannot be collected
+// are left out of the response and counted as collection errors. It is
+// safe for concurrent use.
+type Handler struct {
+	registry *Registry
+	opts     HandlerOpts
+	inFlight chan struct{}
+	errors   *CounterVec
+}
+
+// Compile-time verification that Handler implements http.Handler interface.
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a handler serving the metrics of r.
+func NewHandler(r *Registry, opts HandlerOpts) *Handler {
+	h := &Handler{
+		registry: r,
+		opts:     opts,
+		errors:   NewCounterVec("metrics_scrape_errors_total", []string{"cause"}),
+	}
+	if opts.MaxInFlight > 0 {
+		h.inFlight = make(chan struct{}, opts.MaxInFlight)
+	}
+	return h
+}
+
+// Errors returns the handler's error counter, labeled by cause: "collect",
+// "encode", "timeout", "limit" or "write". It is not registered anywhere;
+// register it in a Registry to expose it.
+func (h *Handler) Errors() *CounterVec {
+	return h.errors
+}
+
+// ServeHTTP encodes the registry in the negotiated format and writes it
+// to w.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if h.inFlight != nil {
+		select {
+		case h.inFlight <- struct{}{}:
+		default:
+			h.fail(w, scrapeErrorLimit, http.StatusServiceUnavailable, "too many concurrent scrapes")
+			return
+		}
+	}
+
+	ctx := req.Context()
+	if h.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.opts.Timeout)
+		defer cancel()
+	}
+
+	format := negotiateFormat(req.Header.Get("Accept"))
+	compress := !h.opts.DisableCompression && acceptsGzip(req.Header.Get("Accept-Encoding"))
+
+	// Encode in the background so a slow scrape can be abandoned. Its
+	// collection stops waiting for collectors once ctx is done, so the
+	// goroutine does not outlive the timeout by much. The in-flight slot
+	// is held until encoding actually finishes.
+	type result struct {
+		body       []byte
+		collectErr error
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		if h.inFlight != nil {
+			defer func() { <-h.inFlight }()
+		}
+		body, collectErr, err := h.encode(ctx, format, compress)
+		done <- result{body: body, collectErr: collectErr, err: err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		// A client that went away gets no response and is not a timeout.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			h.fail(w, scrapeErrorTimeout, http.StatusServiceUnavailable, "scrape timed out")
+		}
+		return
+	}
+	if res.err != nil {
+		h.fail(w, scrapeErrorEncode, http.StatusInternalServerError, "error encoding metrics: "+res.err.Error())
+		return
+	}
+	if res.collectErr != nil {
+		h.incError(scrapeErrorCollect)
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", format.contentType)
+	header.Set("Content-Length", strconv.Itoa(len(res.body)))
+	header.Add("Vary", "Accept-Encoding")
+	if compress {
+		header.Set("Content-Encoding", "gzip")
+	}
+	if _, err := w.Write(res.body); err != nil {
+		h.incError(scrapeErrorWrite)
+	}
+}
+
+// encode collects the registry until ctx is done and renders the samples
+// that could be collected in the given format, optionally gzipped. It
+// returns the collection error separately from the encoding error.
+func (h *Handler) encode(ctx context.Context, format exposition, compress bool) (body []byte, collectErr, err error) {
+	samples, collectErr := h.registry.CollectContext(ctx)
+
+	var buf bytes.Buffer
+
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+
+	if err := format.encode(w, samples); err != nil {
+		return nil, collectErr, err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, collectErr, err
+		}
+	}
+	return buf.Bytes(), collectErr, nil
+}
+
+func (h *Handler) fail(w http.ResponseWriter, cause string, status int, msg string) {
+	h.incError(cause)
+	http.Error(w, msg, status)
+}
+
+func (h *Handler) incError(cause string) {
+	if c, err := h.errors.WithLabelValues(cause); err == nil {
+		c.Inc()
+	}
+}
+
+// exposition is an encoding the handler can serve.
+type exposition struct {
+	contentType string
+	encode      func(io.Writer, []Sample) error
+}
+
+var (
+	prometheusExposition  = exposition{contentType: PrometheusContentType, encode: encodePrometheus}
+	openMetricsExposition = exposition{contentType: OpenMetricsContentType, encode: encodeOpenMetrics}
+	jsonExposition        = exposition{contentType: JSONContentType, encode: encodeJSON}
+)
+
+// negotiateFormat picks the exposition with the highest quality in the
+// Accept header, preferring the earliest on ties. It falls back to the
+// Prometheus text format.
+func negotiateFormat(accept string) exposition {
+	best := prometheusExposition
+	bestQ := 0.0
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, q := parseMediaRange(mediaRange)
+		if q <= bestQ {
+			continue
+		}
+
+		var format exposition
+		switch mediaType {
+		case "application/openmetrics-text":
+			format = openMetricsExposition
+		case "application/json":
+			format = jsonExposition
+		case "text/plain", "text/*", "*/*":
+			format = prometheusExposition
+		default:
+			continue
+		}
+		best, bestQ = format, q
+	}
+
+	return best
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip. An
+// explicit gzip entry takes precedence over a * wildcard.
+func acceptsGzip(acceptEncoding string) bool {
+	wildcard := false
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		switch name, q := parseMediaRange(coding); name {
+		case "gzip":
+			return q > 0
+		case "*":
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
+
+// parseMediaRange splits an Accept-style list element into its lowercased
+// value and its quality, which defaults to 1.
+func parseMediaRange(s string) (string, float64) {
+	parts := strings.Split(s, ";")
+	value := strings.ToLower(strings.TrimSpace(parts[0]))
+
+	q := 1.0
+	for _, param := range parts[1:] {
+		key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.ToLower(strings.TrimSpace(key)) != "q" {
+			continue
+		}
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+			q = parsed
+		}
+	}
+	return value, q
+}
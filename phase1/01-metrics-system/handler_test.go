@@ -0,0 +1,348 @@
+package metrics
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingMetric is a Metric whose Value blocks until release is closed.
+type blockingMetric struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingMetric() *blockingMetric {
+	return &blockingMetric{
+		started: make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+}
+
+func (m *blockingMetric) Name() string     { return "blocking" }
+func (m *blockingMetric) Type() MetricType { return TypeGauge }
+func (m *blockingMetric) Value() interface{} {
+	m.started <- struct{}{}
+	<-m.release
+	return 1.0
+}
+
+func scrape(h http.Handler, accept, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func scrapeErrors(h *Handler, cause string) int64 {
+	c, _ := h.Errors().WithLabelValues(cause)
+	return c.Load()
+}
+
+// TestHandler_ContentNegotiation tests format selection from the Accept header.
+func TestHandler_ContentNegotiation(t *testing.T) {
+	r := NewRegistry(0)
+	c := NewCounter("requests_total")
+	c.Add(3)
+	_ = r.Register(c)
+
+	h := NewHandler(r, HandlerOpts{})
+
+	tests := []struct {
+		name     string
+		accept   string
+		wantType string
+		wantBody string
+	}{
+		{
+			name:     "no accept header",
+			accept:   "",
+			wantType: PrometheusContentType,
+			wantBody: "requests_total 3\n",
+		},
+		{
+			name:     "text plain",
+			accept:   "text/plain;version=0.0.4",
+			wantType: PrometheusContentType,
+			wantBody: "requests_total 3\n",
+		},
+		{
+			name:     "openmetrics preferred",
+			accept:   "application/openmetrics-text;version=1.0.0;q=0.9,text/plain;q=0.5,*/*;q=0.1",
+			wantType: OpenMetricsContentType,
+			wantBody: "# EOF\n",
+		},
+		{
+			name:     "json",
+			accept:   "application/json",
+			wantType: JSONContentType,
+			wantBody: `{"requests_total":3}`,
+		},
+		{
+			name:     "higher quality wins",
+			accept:   "application/json;q=0.2, text/plain;q=0.8",
+			wantType: PrometheusContentType,
+			wantBody: "requests_total 3\n",
+		},
+		{
+			name:     "unsupported type falls back",
+			accept:   "application/xml",
+			wantType: PrometheusContentType,
+			wantBody: "requests_total 3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := scrape(h, tt.accept, "")
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %v, want 200", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestHandler_Gzip tests response compression.
+func TestHandler_Gzip(t *testing.T) {
+	r := NewRegistry(0)
+	_ = r.Register(NewGauge("temperature"))
+
+	t.Run("compresses when accepted", func(t *testing.T) {
+		rec := scrape(NewHandler(r, HandlerOpts{}), "", "deflate, gzip;q=0.8")
+
+		if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("gzip.NewReader() error = %v", err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("reading gzip body: %v", err)
+		}
+		if want := "temperature 0\n"; !strings.Contains(string(body), want) {
+			t.Errorf("decompressed body = %q, want it to contain %q", body, want)
+		}
+	})
+
+	t.Run("does not compress when refused", func(t *testing.T) {
+		rec := scrape(NewHandler(r, HandlerOpts{}), "", "gzip;q=0")
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none", got)
+		}
+	})
+
+	t.Run("refusal overrides wildcard", func(t *testing.T) {
+		rec := scrape(NewHandler(r, HandlerOpts{}), "", "gzip;q=0, *")
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none", got)
+		}
+	})
+
+	t.Run("wildcard accepts gzip", func(t *testing.T) {
+		rec := scrape(NewHandler(r, HandlerOpts{}), "", "identity, *")
+
+		if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Errorf("Content-Encoding = %q, want gzip", got)
+		}
+	})
+
+	t.Run("compression can be disabled", func(t *testing.T) {
+		rec := scrape(NewHandler(r, HandlerOpts{DisableCompression: true}), "", "gzip")
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none", got)
+		}
+	})
+}
+
+// TestHandler_Timeout tests that slow scrapes are abandoned.
+func TestHandler_Timeout(t *testing.T) {
+	r := NewRegistry(0)
+	m := newBlockingMetric()
+	defer close(m.release)
+	_ = r.Register(m)
+
+	h := NewHandler(r, HandlerOpts{Timeout: 10 * time.Millisecond})
+	rec := scrape(h, "", "")
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %v, want 503", rec.Code)
+	}
+	if got := scrapeErrors(h, "timeout"); got != 1 {
+		t.Errorf("timeout errors = %v, want 1", got)
+	}
+}
+
+// TestHandler_TimeoutCancelsCollectors tests that the collectors of an
+// abandoned scrape are cancelled rather than left running.
+func TestHandler_TimeoutCancelsCollectors(t *testing.T) {
+	r := NewRegistry(0)
+	cancelled := make(chan struct{})
+	_ = r.RegisterCollector(&stubCollector{
+		descs: []Desc{{Name: "slow", Type: TypeGauge}},
+		collect: func(ctx context.Context) ([]Sample, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		},
+	})
+
+	h := NewHandler(r, HandlerOpts{Timeout: 10 * time.Millisecond})
+	if rec := scrape(h, "", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %v, want 503", rec.Code)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("collector of the timed out scrape was not cancelled")
+	}
+}
+
+// TestHandler_MaxInFlight tests the concurrent scrape limit.
+func TestHandler_MaxInFlight(t *testing.T) {
+	r := NewRegistry(0)
+	m := newBlockingMetric()
+	_ = r.Register(m)
+
+	h := NewHandler(r, HandlerOpts{MaxInFlight: 1})
+
+	first := make(chan int)
+	go func() {
+		first <- scrape(h, "", "").Code
+	}()
+	<-m.started
+
+	if rec := scrape(h, "", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("second concurrent scrape status = %v, want 503", rec.Code)
+	}
+	if got := scrapeErrors(h, "limit"); got != 1 {
+		t.Errorf("limit errors = %v, want 1", got)
+	}
+
+	close(m.release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first scrape status = %v, want 200", code)
+	}
+}
+
+// TestHandler_CollectError tests that metrics which cannot be collected
+// are counted and left out while the others are served.
+func TestHandler_CollectError(t *testing.T) {
+	r := NewRegistry(0)
+	_ = r.Register(unsupportedMetric{})
+	_ = r.Register(NewGauge("healthy"))
+
+	h := NewHandler(r, HandlerOpts{})
+	rec := scrape(h, "", "")
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %v, want 200", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "healthy 0\n") {
+		t.Errorf("body = %q, want the healthy gauge", body)
+	}
+	if got := scrapeErrors(h, "collect"); got != 1 {
+		t.Errorf("collect errors = %v, want 1", got)
+	}
+	if got := scrapeErrors(h, "encode"); got != 0 {
+		t.Errorf("encode errors = %v, want 0", got)
+	}
+}
+
+// TestHandler_HungCallback tests that a callback that never returns does
+// not keep the other metrics from being served.
+func TestHandler_HungCallback(t *testing.T) {
+	r := NewRegistry(0, WithCollectorTimeout(10*time.Millisecond))
+	release := make(chan struct{})
+	defer close(release)
+	_ = r.RegisterCollector(NewGaugeFunc("hung", func() float64 {
+		<-release
+		return 0
+	}))
+	_ = r.Register(NewGauge("healthy"))
+
+	h := NewHandler(r, HandlerOpts{})
+	for i := 0; i < 3; i++ {
+		rec := scrape(h, "", "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("scrape %d status = %v, want 200", i, rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "healthy 0\n") {
+			t.Errorf("scrape %d body = %q, want the healthy gauge", i, body)
+		}
+	}
+	// Only the scrape that timed out reports the hung callback.
+	if got := scrapeErrors(h, "collect"); got != 1 {
+		t.Errorf("collect errors = %v, want 1", got)
+	}
+}
+
+// TestHandler_ClientGone tests that a scrape abandoned by its client is not
+// counted as a timeout.
+func TestHandler_ClientGone(t *testing.T) {
+	r := NewRegistry(0)
+	m := newBlockingMetric()
+	defer close(m.release)
+	_ = r.Register(m)
+
+	h := NewHandler(r, HandlerOpts{Timeout: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil).WithContext(ctx)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := scrapeErrors(h, "timeout"); got != 0 {
+		t.Errorf("timeout errors = %v, want 0", got)
+	}
+}
+
+// TestWriteJSON tests the JSON encoder.
+func TestWriteJSON(t *testing.T) {
+	r := NewRegistry(0)
+
+	h := NewHistogram("latency_seconds", []float64{1})
+	h.Observe(0.5)
+	_ = r.Register(h)
+	_ = r.Register(NewSummary("rpc_seconds", SummaryConfig{Objectives: map[float64]float64{0.5: 0.05}}))
+
+	var buf strings.Builder
+	if err := WriteJSON(&buf, r); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(buf.String()), &got); err != nil {
+		t.Fatalf("WriteJSON() produced invalid JSON %q: %v", buf.String(), err)
+	}
+
+	want := `{"latency_seconds":{"buckets":[{"le":1,"count":1},{"le":"+Inf","count":1}],"count":1,"sum":0.5},` +
+		`"rpc_seconds":{"quantiles":{"0.5":"NaN"},"count":0,"sum":0}}` + "\n"
+	if buf.String() != want {
+		t.Errorf("WriteJSON() = %s, want %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// DefaultBuckets are the default histogram bucket upper bounds, tuned for
+// measuring request latencies in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram is a metric that samples observations into configurable buckets
+// and tracks their count and sum. It is safe for concurrent use by multiple
+// goroutines. The zero value is ready to use and has a single +Inf bucket.
+type Histogram struct {
+	name        string
+	desc        *Desc
+	labels      Labels
+	created     time.Time
+	upperBounds []float64
+	counts      []atomic.Uint64 // one per upper bound, non-cumulative
+	overflow    atomic.Uint64   // observations above the largest upper bound
+	sum         atomic.Float64
+
+	exemplars   []atomic.Pointer[Exemplar] // one per upper bound
+	infExemplar atomic.Pointer[Exemplar]
+	updateTracker
+}
+
+// Compile-time verification that Histogram implements Metric interface.
+var _ Metric = (*Histogram)(nil)
+
+// Bucket is a single cumulative histogram bucket.
+type Bucket struct {
+	// UpperBound is the inclusive upper bound of the bucket.
+	UpperBound float64
+
+	// Count is the number of observations less than or equal to UpperBound.
+	Count uint64
+
+	// Exemplar is the most recent exemplar observed in this bucket, if any.
+	Exemplar *Exemplar
+}
+
+// HistogramSnapshot is a point-in-time copy of a histogram's state.
+// The implicit +Inf bucket is not included in Buckets; its count equals Count.
+type HistogramSnapshot struct {
+	Buckets []Bucket
+	Count   uint64
+	Sum     float64
+
+	// InfExemplar is the most recent exemplar observed above the largest
+	// bucket upper bound, if any.
+	InfExemplar *Exemplar
+}
+
+// NewHistogram creates a new histogram metric with the given name, bucket
+// upper bounds and options. If buckets is empty, DefaultBuckets is used.
+// The bounds are copied, sorted and de-duplicated; NaN and +Inf bounds are
+// dropped since the +Inf bucket is always implicit.
+func NewHistogram(name string, buckets []float64, opts ...Option) *Histogram {
+	desc := newDesc(name, TypeHistogram, nil, opts)
+	return newHistogram(&desc, desc.ConstLabels, normalizeBuckets(buckets))
+}
+
+// newHistogram creates a histogram with already normalized upper bounds.
+func newHistogram(desc *Desc, labels Labels, upperBounds []float64) *Histogram {
+	return &Histogram{
+		name:        desc.Name,
+		desc:        desc,
+		labels:      labels,
+		created:     time.Now(),
+		upperBounds: upperBounds,
+		counts:      make([]atomic.Uint64, len(upperBounds)),
+		exemplars:   make([]atomic.Pointer[Exemplar], len(upperBounds)),
+	}
+}
+
+// Name returns the name of this histogram metric.
+func (h *Histogram) Name() string {
+	return h.name
+}
+
+// Desc returns the descriptor of this histogram.
+func (h *Histogram) Desc() Desc {
+	return describe(h.name, TypeHistogram, h.desc)
+}
+
+// Labels returns a copy of the labels of this histogram: its constant
+// labels and, for children of a HistogramVec, its variable labels.
+func (h *Histogram) Labels() Labels {
+	return h.labels.clone()
+}
+
+// Created returns the time the histogram was created, or the zero time for
+// a zero-value Histogram.
+func (h *Histogram) Created() time.Time {
+	return h.created
+}
+
+// Type returns TypeHistogram, indicating this is a histogram metric.
+func (h *Histogram) Type() MetricType {
+	return TypeHistogram
+}
+
+// Value returns the current state of the histogram as an interface{}.
+// The underlying type is HistogramSnapshot.
+func (h *Histogram) Value() interface{} {
+	return h.Snapshot()
+}
+
+// Observe adds a single observation to the histogram.
+// This operation is atomic and safe for concurrent use.
+func (h *Histogram) Observe(value float64) {
+	h.observe(value)
+}
+
+// ObserveWithExemplar adds a single observation to the histogram, like
+// Observe, and records an exemplar with the given labels, such as a trace
+// ID, for the bucket the observation falls into. Only the most recent
+// exemplar per bucket is kept.
+// It returns ErrInvalidExemplar if the labels are not a valid exemplar
+// label set; the observation is recorded regardless.
+func (h *Histogram) ObserveWithExemplar(value float64, labels Labels) error {
+	i := h.observe(value)
+
+	exemplar, err := newExemplar(labels, value)
+	if err != nil {
+		return err
+	}
+	if i < len(h.exemplars) {
+		h.exemplars[i].Store(exemplar)
+	} else {
+		h.infExemplar.Store(exemplar)
+	}
+	return nil
+}
+
+// observe records value and returns the index of its bucket, which is
+// len(h.upperBounds) for the implicit +Inf bucket.
+func (h *Histogram) observe(value float64) int {
+	// SearchFloat64s returns the first bound >= value, which matches the
+	// inclusive "less than or equal" semantics of bucket upper bounds.
+	i := sort.SearchFloat64s(h.upperBounds, value)
+	if i < len(h.counts) {
+		h.counts[i].Inc()
+	} else {
+		h.overflow.Inc()
+	}
+	h.sum.Add(value)
+	h.touch()
+	return i
+}
+
+// Snapshot returns a copy of the histogram's cumulative bucket counts,
+// total count, sum and exemplars.
+//
+// Each field is read atomically, but observations that race with Snapshot
+// may be reflected in some fields and not others.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	buckets := make([]Bucket, len(h.upperBounds))
+
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += h.counts[i].Load()
+		buckets[i] = Bucket{
+			UpperBound: bound,
+			Count:      cumulative,
+			Exemplar:   h.exemplars[i].Load().clone(),
+		}
+	}
+
+	return HistogramSnapshot{
+		Buckets:     buckets,
+		Count:       cumulative + h.overflow.Load(),
+		Sum:         h.sum.Load(),
+		InfExemplar: h.infExemplar.Load().clone(),
+	}
+}
+
+// LinearBuckets returns count bucket upper bounds, the first being start and
+// each following bound width larger than the previous one.
+// It returns nil if count is less than 1 or width is not positive.
+func LinearBuckets(start, width float64, count int) []float64 {
+	if count < 1 || width <= 0 {
+		return nil
+	}
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start + float64(i)*width
+	}
+	return buckets
+}
+
+// ExponentialBuckets returns count bucket upper bounds, the first being start
+// and each following bound factor times the previous one.
+// It returns nil if count is less than 1, start is not positive, or factor is
+// not greater than 1.
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	if count < 1 || start <= 0 || factor <= 1 {
+		return nil
+	}
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// normalizeBuckets returns a sorted, de-duplicated copy of buckets without
+// NaN and +Inf bounds, or a copy of DefaultBuckets if buckets is empty.
+func normalizeBuckets(buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	upperBounds := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		if math.IsNaN(b) || math.IsInf(b, 1) {
+			continue
+		}
+		upperBounds = append(upperBounds, b)
+	}
+	sort.Float64s(upperBounds)
+	return dedupSorted(upperBounds)
+}
+
+// dedupSorted removes adjacent duplicates from a sorted slice in place.
+func dedupSorted(values []float64) []float64 {
+	if len(values) < 2 {
+		return values
+	}
+
+	out := values[:1]
+	for _, v := range values[1:] {
+		if v != out[len(out)-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
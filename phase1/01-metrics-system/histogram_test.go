@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestHistogram tests the Histogram implementation.
+func TestHistogram(t *testing.T) {
+	t.Run("zero value is usable", func(t *testing.T) {
+		var h Histogram
+		h.name = "test"
+
+		h.Observe(1.5)
+		h.Observe(2.5)
+
+		got := h.Snapshot()
+		if got.Count != 2 {
+			t.Errorf("zero value Histogram count = %v, want 2", got.Count)
+		}
+		if got.Sum != 4.0 {
+			t.Errorf("zero value Histogram sum = %v, want 4.0", got.Sum)
+		}
+		if len(got.Buckets) != 0 {
+			t.Errorf("zero value Histogram has %d buckets, want 0", len(got.Buckets))
+		}
+	})
+
+	t.Run("NewHistogram creates histogram with name", func(t *testing.T) {
+		h := NewHistogram("test_histogram", nil)
+
+		if got := h.Name(); got != "test_histogram" {
+			t.Errorf("Histogram.Name() = %v, want test_histogram", got)
+		}
+
+		if got := h.Type(); got != TypeHistogram {
+			t.Errorf("Histogram.Type() = %v, want %v", got, TypeHistogram)
+		}
+
+		if got := len(h.Snapshot().Buckets); got != len(DefaultBuckets) {
+			t.Errorf("default Histogram has %d buckets, want %d", got, len(DefaultBuckets))
+		}
+	})
+
+	t.Run("NewHistogram normalizes buckets", func(t *testing.T) {
+		input := []float64{5, 1, math.Inf(1), 1, math.NaN(), 2}
+		h := NewHistogram("test", input)
+
+		var got []float64
+		for _, b := range h.Snapshot().Buckets {
+			got = append(got, b.UpperBound)
+		}
+
+		want := []float64{1, 2, 5}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("bucket bounds = %v, want %v", got, want)
+		}
+
+		if input[0] != 5 {
+			t.Error("NewHistogram modified the caller's bucket slice")
+		}
+	})
+
+	t.Run("Observe fills cumulative buckets", func(t *testing.T) {
+		h := NewHistogram("test", []float64{1, 2, 5})
+
+		for _, v := range []float64{0.5, 1, 1.5, 3, 10} {
+			h.Observe(v)
+		}
+
+		got := h.Snapshot()
+		want := HistogramSnapshot{
+			Buckets: []Bucket{
+				{UpperBound: 1, Count: 2},
+				{UpperBound: 2, Count: 3},
+				{UpperBound: 5, Count: 4},
+			},
+			Count: 5,
+			Sum:   16,
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Histogram.Snapshot() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("Value returns interface{}", func(t *testing.T) {
+		h := NewHistogram("test", []float64{1})
+		h.Observe(0.5)
+
+		value := h.Value()
+		got, ok := value.(HistogramSnapshot)
+		if !ok {
+			t.Fatalf("Histogram.Value() returned type %T, want HistogramSnapshot", value)
+		}
+		if got.Count != 1 {
+			t.Errorf("Histogram.Value().Count = %v, want 1", got.Count)
+		}
+	})
+
+	t.Run("implements Metric interface", func(t *testing.T) {
+		var _ Metric = (*Histogram)(nil)
+	})
+}
+
+// TestHistogram_Concurrent tests histogram operations under concurrent access.
+func TestHistogram_Concurrent(t *testing.T) {
+	h := NewHistogram("concurrent_test", []float64{1, 2})
+	const goroutines = 100
+	const observations = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		i := i // capture loop variable
+		go func() {
+			defer wg.Done()
+			for j := 0; j < observations; j++ {
+				h.Observe(float64(i % 3))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	got := h.Snapshot()
+	if want := uint64(goroutines * observations); got.Count != want {
+		t.Errorf("concurrent Histogram count = %v, want %v", got.Count, want)
+	}
+	if got.Buckets[len(got.Buckets)-1].Count > got.Count {
+		t.Errorf("cumulative bucket count %v exceeds total %v",
+			got.Buckets[len(got.Buckets)-1].Count, got.Count)
+	}
+}
+
+// TestLinearBuckets tests the LinearBuckets generator.
+func TestLinearBuckets(t *testing.T) {
+	tests := []struct {
+		name  string
+		start float64
+		width float64
+		count int
+		want  []float64
+	}{
+		{
+			name:  "positive width",
+			start: 1,
+			width: 2,
+			count: 4,
+			want:  []float64{1, 3, 5, 7},
+		},
+		{
+			name:  "zero count",
+			start: 1,
+			width: 2,
+			count: 0,
+			want:  nil,
+		},
+		{
+			name:  "non-positive width",
+			start: 1,
+			width: 0,
+			count: 3,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LinearBuckets(tt.start, tt.width, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LinearBuckets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExponentialBuckets tests the ExponentialBuckets generator.
+func TestExponentialBuckets(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  float64
+		factor float64
+		count  int
+		want   []float64
+	}{
+		{
+			name:   "doubling",
+			start:  1,
+			factor: 2,
+			count:  4,
+			want:   []float64{1, 2, 4, 8},
+		},
+		{
+			name:   "non-positive start",
+			start:  0,
+			factor: 2,
+			count:  4,
+			want:   nil,
+		},
+		{
+			name:   "factor not greater than 1",
+			start:  1,
+			factor: 1,
+			count:  4,
+			want:   nil,
+		},
+		{
+			name:   "zero count",
+			start:  1,
+			factor: 2,
+			count:  0,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExponentialBuckets(tt.start, tt.factor, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExponentialBuckets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRegistry_Histogram tests histogram registration.
+func TestRegistry_Histogram(t *testing.T) {
+	r := NewRegistry(0)
+	h := NewHistogram("latency_seconds", []float64{0.1, 1})
+	h.Observe(0.05)
+
+	if err := r.Register(h); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	snapshot, ok := r.Snapshot()["latency_seconds"].(HistogramSnapshot)
+	if !ok {
+		t.Fatalf("snapshot['latency_seconds'] type = %T, want HistogramSnapshot",
+			r.Snapshot()["latency_seconds"])
+	}
+	if snapshot.Count != 1 {
+		t.Errorf("snapshot['latency_seconds'].Count = %v, want 1", snapshot.Count)
+	}
+}
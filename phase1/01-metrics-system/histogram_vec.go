@@ -0,0 +1,80 @@
+package metrics
+
+// HistogramVec is a family of histograms that share a name and are
+// distinguished by label values, e.g. request_duration_seconds{route="/users"}.
+// Children are created on first use and cached. It is safe for concurrent
+// use by multiple goroutines.
+type HistogramVec struct {
+	vec *metricVec[*Histogram]
+}
+
+// Compile-time verification that HistogramVec implements metricFamily.
+var _ metricFamily = (*HistogramVec)(nil)
+
+// NewHistogramVec creates a new histogram vector with the given name,
+// label names, bucket upper bounds and options. The buckets are shared by
+// all children and normalized as in NewHistogram.
+func NewHistogramVec(name string, labelNames []string, buckets []float64, opts ...Option) *HistogramVec {
+	desc := newDesc(name, TypeHistogram, labelNames, opts)
+	upperBounds := normalizeBuckets(buckets)
+	return &HistogramVec{
+		vec: newMetricVec(desc, func(labels Labels) *Histogram {
+			return newHistogram(&desc, labels, upperBounds)
+		}),
+	}
+}
+
+// Name returns the name shared by all histograms in this vector.
+func (v *HistogramVec) Name() string {
+	return v.vec.desc.Name
+}
+
+// Desc returns the descriptor shared by all histograms in this vector.
+func (v *HistogramVec) Desc() Desc {
+	return v.vec.desc.clone()
+}
+
+// Type returns TypeHistogram, the type of every child in this vector.
+func (v *HistogramVec) Type() MetricType {
+	return TypeHistogram
+}
+
+// Value returns the current values of all children as an interface{}.
+// The underlying type is map[string]interface{}, keyed by the children's
+// label sets as rendered by Labels.String, with HistogramSnapshot values.
+func (v *HistogramVec) Value() interface{} {
+	return v.vec.value()
+}
+
+// LabelNames returns a copy of the label names declared for this vector.
+func (v *HistogramVec) LabelNames() []string {
+	return append([]string(nil), v.vec.labelNames...)
+}
+
+// WithLabelValues returns the histogram for the given label values, given in
+// the order of the declared label names, creating it if needed.
+// It returns ErrLabelMismatch if the number of values is wrong.
+func (v *HistogramVec) WithLabelValues(values ...string) (*Histogram, error) {
+	return v.vec.withLabelValues(values)
+}
+
+// With returns the histogram for the given labels, creating it if needed.
+// It returns ErrLabelMismatch if the labels differ from the declared names.
+func (v *HistogramVec) With(labels Labels) (*Histogram, error) {
+	return v.vec.with(labels)
+}
+
+// Delete removes the histogram for the given labels.
+// It reports whether a histogram was removed.
+func (v *HistogramVec) Delete(labels Labels) bool {
+	return v.vec.delete(labels)
+}
+
+// Reset removes all histograms from this vector.
+func (v *HistogramVec) Reset() {
+	v.vec.reset()
+}
+
+func (v *HistogramVec) children() []Metric {
+	return v.vec.children()
+}
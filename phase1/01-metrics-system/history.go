@@ -0,0 +1,287 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+const (
+	// DefaultHistoryInterval is the default interval between the samples
+	// recorded by a History.
+	DefaultHistoryInterval = 10 * time.Second
+
+	// DefaultHistorySize is the default number of points kept per series,
+	// an hour at the default interval.
+	DefaultHistorySize = 360
+
+	// DefaultHistoryMaxBytes is the default memory budget of a History.
+	DefaultHistoryMaxBytes = 4 << 20
+)
+
+// historyPointSize is the memory used by a point of a ring buffer.
+const historyPointSize = int(unsafe.Sizeof(historyPoint{}))
+
+// historySeriesOverhead is the memory used by a series besides its key and
+// points: the ring buffer header, and its entry in the series map with the
+// map's own bookkeeping, which is estimated generously.
+const historySeriesOverhead = int(unsafe.Sizeof(historySeries{})) + 64
+
+// HistoryConfig configures a History. The zero value selects the defaults.
+type HistoryConfig struct {
+	// Interval is the time between samples when running with Run.
+	// Defaults to DefaultHistoryInterval.
+	Interval time.Duration
+
+	// Size is the number of points kept per series; older points are
+	// overwritten. Defaults to DefaultHistorySize.
+	Size int
+
+	// MaxBytes bounds the memory of the recorded series. Every series
+	// costs Size points of 16 bytes, allocated when it is first recorded,
+	// plus its name and labels and about a hundred bytes of bookkeeping;
+	// series beyond the budget are not recorded. At least one series is
+	// always recorded. Defaults to DefaultHistoryMaxBytes.
+	MaxBytes int
+
+	// Clock is the time source of the points.
+	// Defaults to the system clock.
+	Clock Clock
+}
+
+// HistoryPoint is a recorded value of a series.
+type HistoryPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// History records the recent values of the metrics of a Registry in
+// fixed-size ring buffers, one per series, so that the last values of a
+// metric can be inspected when debugging rather than only its current one.
+// It is opt-in: nothing is recorded unless Run, Record or Report is called.
+//
+// Counters and gauges are recorded under their name and labels. Histograms
+// and summaries are recorded as their name_count and name_sum series, and
+// meters as the count of their events.
+//
+// Memory is bounded by MaxBytes. A series whose latest point has fallen
+// out of the time its ring buffer spans is forgotten, freeing its buffer.
+// The points of new series that do not fit in the budget are counted by
+// Dropped.
+//
+// A History is also a Reporter, so it can record the samples of a
+// ReportLoop instead of collecting the registry itself.
+//
+// A History is safe for concurrent use.
+type History struct {
+	registry *Registry
+	cfg      HistoryConfig
+	clock    Clock
+	dropped  *Counter
+
+	mu     sync.Mutex
+	series map[string]*historySeries
+	bytes  int // memory of the series, see historySeriesCost
+}
+
+// Compile-time verification that History implements Reporter.
+var _ Reporter = (*History)(nil)
+
+// historyPoint is a point of a ring buffer. The time is kept in Unix
+// nanoseconds, so that buffers hold no pointers for the garbage collector
+// to scan.
+type historyPoint struct {
+	unixNano int64
+	value    float64
+}
+
+// historySeries is the ring buffer of a series.
+type historySeries struct {
+	points []historyPoint
+	next   int // index of the next write
+	count  int // number of points written, up to len(points)
+}
+
+// NewHistory creates a history of the metrics of r. Call Run to record in
+// the background, or Record to record once. r may be nil if the history
+// only records the samples passed to Report; Run and Record then return an
+// error.
+func NewHistory(r *Registry, cfg HistoryConfig) *History {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultHistoryInterval
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultHistorySize
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultHistoryMaxBytes
+	}
+
+	return &History{
+		registry: r,
+		cfg:      cfg,
+		clock:    clockOrDefault(cfg.Clock),
+		dropped:  NewCounter("history_points_dropped_total"),
+		series:   make(map[string]*historySeries),
+	}
+}
+
+// Dropped returns the counter of points that were not recorded because
+// the memory budget was exhausted. It is not registered anywhere;
+// register it in a Registry to expose it.
+func (h *History) Dropped() *Counter {
+	return h.dropped
+}
+
+// Run records every interval until ctx is cancelled, using a ReportLoop.
+// Samples are recorded even if some metrics could not be collected. It
+// returns an error if the loop cannot be created, as when the history has
+// no registry, and nil once ctx is cancelled.
+func (h *History) Run(ctx context.Context) error {
+	loop, err := NewReportLoop(h.registry, ReportLoopConfig{
+		Interval: h.cfg.Interval,
+		Sinks:    []ReportSink{{Name: "history", Reporter: h}},
+	})
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	loop.Run(ctx)
+	return nil
+}
+
+// Record collects the registry and records the samples. Like
+// Registry.Collect, it records the samples that could be collected and
+// returns an error describing the others. It returns an error if the
+// history has no registry.
+func (h *History) Record() error {
+	if h.registry == nil {
+		return errors.New("history: no registry to record")
+	}
+	samples, err := h.registry.Collect()
+	return errors.Join(err, h.Report(context.Background(), samples))
+}
+
+// Report records samples at the current time of the clock.
+func (h *History) Report(_ context.Context, samples []Sample) error {
+	now := h.clock.Now().UnixNano()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.forgetLocked(now)
+	for _, s := range samples {
+		switch {
+		case s.Histogram != nil:
+			h.addLocked(s.Name+"_count", s.Labels, now, float64(s.Histogram.Count))
+			h.addLocked(s.Name+"_sum", s.Labels, now, s.Histogram.Sum)
+		case s.Summary != nil:
+			h.addLocked(s.Name+"_count", s.Labels, now, float64(s.Summary.Count))
+			h.addLocked(s.Name+"_sum", s.Labels, now, s.Summary.Sum)
+		case s.Meter != nil:
+			h.addLocked(s.Name, s.Labels, now, float64(s.Meter.Count))
+		default:
+			h.addLocked(s.Name, s.Labels, now, s.Value)
+		}
+	}
+	return nil
+}
+
+// Range returns the recorded points of the series with the given name and
+// labels whose time is within [from, to], oldest first. It returns nil if
+// the series is not recorded.
+func (h *History) Range(name string, labels Labels, from, to time.Time) []HistoryPoint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[metricKey(name, labels)]
+	if !ok {
+		return nil
+	}
+
+	lo, hi := from.UnixNano(), to.UnixNano()
+	points := s.ordered()
+	// Points are recorded in time order, so the range can be searched.
+	start := sort.Search(len(points), func(i int) bool { return points[i].unixNano >= lo })
+	end := sort.Search(len(points), func(i int) bool { return points[i].unixNano > hi })
+
+	var result []HistoryPoint
+	for _, p := range points[start:max(start, end)] {
+		result = append(result, HistoryPoint{Time: time.Unix(0, p.unixNano), Value: p.value})
+	}
+	return result
+}
+
+// Len returns the number of recorded series.
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.series)
+}
+
+// addLocked appends a point to a series, creating its ring buffer if the
+// budget allows. h.mu must be held.
+func (h *History) addLocked(name string, labels Labels, now int64, value float64) {
+	key := metricKey(name, labels)
+	s, ok := h.series[key]
+	if !ok {
+		cost := historySeriesCost(key, h.cfg.Size)
+		if len(h.series) > 0 && h.bytes+cost > h.cfg.MaxBytes {
+			h.dropped.Inc()
+			return
+		}
+		s = &historySeries{points: make([]historyPoint, h.cfg.Size)}
+		h.series[key] = s
+		h.bytes += cost
+	}
+	s.add(historyPoint{unixNano: now, value: value})
+}
+
+// forgetLocked frees the series whose latest point is older than the time
+// a ring buffer spans. h.mu must be held.
+func (h *History) forgetLocked(now int64) {
+	span := int64(h.cfg.Size) * int64(h.cfg.Interval)
+	for key, s := range h.series {
+		if now-s.latest().unixNano > span {
+			delete(h.series, key)
+			h.bytes -= historySeriesCost(key, h.cfg.Size)
+		}
+	}
+}
+
+// historySeriesCost returns the memory used by a series with the given key
+// and number of points.
+func historySeriesCost(key string, size int) int {
+	return len(key) + historySeriesOverhead + size*historyPointSize
+}
+
+// add writes p over the oldest point once the buffer is full. A point
+// older than the latest one, from a clock that went backwards, replaces
+// the whole buffer so that points stay in time order.
+func (s *historySeries) add(p historyPoint) {
+	if s.count > 0 && p.unixNano < s.latest().unixNano {
+		s.next, s.count = 0, 0
+	}
+	s.points[s.next] = p
+	s.next = (s.next + 1) % len(s.points)
+	s.count = min(s.count+1, len(s.points))
+}
+
+// latest returns the most recent point. The series must not be empty.
+func (s *historySeries) latest() historyPoint {
+	return s.points[(s.next-1+len(s.points))%len(s.points)]
+}
+
+// ordered returns the points oldest first, in a new slice.
+func (s *historySeries) ordered() []historyPoint {
+	points := make([]historyPoint, 0, s.count)
+	first := (s.next - s.count + len(s.points)) % len(s.points)
+	for i := range s.count {
+		points = append(points, s.points[(first+i)%len(s.points)])
+	}
+	return points
+}
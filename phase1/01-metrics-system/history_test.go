@@ -0,0 +1,258 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// historyValues returns the values of points, and fails the test if their
+// times are not spaced by interval starting at start.
+func historyValues(t *testing.T, points []HistoryPoint, start time.Time, interval time.Duration) []float64 {
+	t.Helper()
+
+	values := make([]float64, 0, len(points))
+	for i, p := range points {
+		if want := start.Add(time.Duration(i) * interval); !p.Time.Equal(want) {
+			t.Errorf("point %d time = %v, want %v", i, p.Time, want)
+		}
+		values = append(values, p.Value)
+	}
+	return values
+}
+
+func TestHistory(t *testing.T) {
+	const interval = 10 * time.Second
+
+	t.Run("records values and returns a time range", func(t *testing.T) {
+		clock := newFakeClock()
+		start := clock.Now()
+		r := NewRegistry(0)
+		g := NewGauge("queue_depth")
+		_ = r.Register(g)
+		h := NewHistory(r, HistoryConfig{Interval: interval, Size: 10, Clock: clock})
+
+		for i := range 5 {
+			g.Set(float64(i))
+			if err := h.Record(); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			clock.Advance(interval)
+		}
+
+		tests := []struct {
+			name     string
+			from, to time.Time
+			want     []float64 // values of points recorded from start
+		}{
+			{name: "all", from: start, to: clock.Now(), want: []float64{0, 1, 2, 3, 4}},
+			{name: "inclusive bounds", from: start.Add(interval), to: start.Add(3 * interval), want: []float64{1, 2, 3}},
+			{name: "between points", from: start.Add(interval / 2), to: start.Add(2*interval + interval/2), want: []float64{1, 2}},
+			{name: "before recording", from: start.Add(-time.Hour), to: start.Add(-time.Minute), want: []float64{}},
+			{name: "inverted", from: clock.Now(), to: start, want: []float64{}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				points := h.Range("queue_depth", nil, tt.from, tt.to)
+				var first time.Time
+				if len(tt.want) > 0 {
+					first = start.Add(time.Duration(tt.want[0]) * interval)
+				}
+				got := historyValues(t, points, first, interval)
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Range() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("overwrites the oldest points", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		c := NewCounter("jobs_total")
+		_ = r.Register(c)
+		h := NewHistory(r, HistoryConfig{Interval: interval, Size: 3, Clock: clock})
+
+		for range 5 {
+			c.Inc()
+			_ = h.Record()
+			clock.Advance(interval)
+		}
+
+		points := h.Range("jobs_total", nil, time.Time{}, clock.Now())
+		got := historyValues(t, points, newFakeClock().Now().Add(2*interval), interval)
+		if want := []float64{3, 4, 5}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Range() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("flattens labels, histograms, summaries and meters", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		vec := NewGaugeVec("temperature", []string{"room"})
+		kitchen, _ := vec.WithLabelValues("kitchen")
+		kitchen.Set(21.5)
+		_ = r.Register(vec)
+		hist := NewHistogram("latency_seconds", []float64{0.1, 1})
+		hist.Observe(0.5)
+		hist.Observe(2)
+		_ = r.Register(hist)
+		sum := NewSummary("size_bytes", SummaryConfig{})
+		sum.Observe(100)
+		_ = r.Register(sum)
+		meter := NewMeter("events", MeterConfig{Clock: clock})
+		meter.Mark(7)
+		_ = r.Register(meter)
+
+		h := NewHistory(r, HistoryConfig{Clock: clock})
+		_ = h.Record()
+
+		tests := []struct {
+			name   string
+			labels Labels
+			want   float64
+		}{
+			{name: "temperature", labels: Labels{"room": "kitchen"}, want: 21.5},
+			{name: "latency_seconds_count", want: 2},
+			{name: "latency_seconds_sum", want: 2.5},
+			{name: "size_bytes_count", want: 1},
+			{name: "size_bytes_sum", want: 100},
+			{name: "events", want: 7},
+		}
+		for _, tt := range tests {
+			points := h.Range(tt.name, tt.labels, clock.Now(), clock.Now())
+			if len(points) != 1 || points[0].Value != tt.want {
+				t.Errorf("Range(%s%s) = %v, want one point of %v", tt.name, tt.labels, points, tt.want)
+			}
+		}
+		if got := h.Range("temperature", nil, clock.Now(), clock.Now()); got != nil {
+			t.Errorf("Range() without labels = %v, want nil", got)
+		}
+	})
+
+	t.Run("series beyond the memory budget are dropped", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		for _, name := range []string{"a", "b", "c"} {
+			_ = r.Register(NewGauge(name))
+		}
+		// Room for two series of four points.
+		budget := historySeriesCost("a", 4) + historySeriesCost("b", 4)
+		h := NewHistory(r, HistoryConfig{Size: 4, MaxBytes: budget, Clock: clock})
+
+		_ = h.Record()
+		_ = h.Record()
+
+		if got := h.Len(); got != 2 {
+			t.Errorf("Len() = %d, want 2", got)
+		}
+		if got := h.Range("c", nil, time.Time{}, clock.Now()); got != nil {
+			t.Errorf("Range(c) = %v, want nil", got)
+		}
+		if got := h.Dropped().Load(); got != 2 {
+			t.Errorf("Dropped() = %d, want 2", got)
+		}
+	})
+
+	t.Run("heap growth stays within the memory budget", func(t *testing.T) {
+		const maxBytes = 1 << 20
+		samples := make([]Sample, 1<<16)
+		for i := range samples {
+			samples[i] = Sample{Name: fmt.Sprintf("series_%05d", i), Type: TypeGauge, Value: 1}
+		}
+		h := NewHistory(nil, HistoryConfig{Size: 1, MaxBytes: maxBytes, Clock: newFakeClock()})
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		_ = h.Report(context.Background(), samples)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(samples)
+
+		if h.Dropped().Load() == 0 {
+			t.Fatal("Dropped() = 0, want series beyond the budget to be dropped")
+		}
+		if h.bytes > maxBytes {
+			t.Errorf("accounted bytes = %d, want at most %d", h.bytes, maxBytes)
+		}
+		if growth := int64(after.HeapAlloc) - int64(before.HeapAlloc); growth > maxBytes {
+			t.Errorf("heap grew by %d bytes for %d series, want at most %d", growth, h.Len(), maxBytes)
+		}
+	})
+
+	t.Run("forgets series that stopped reporting", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("removed"))
+		h := NewHistory(r, HistoryConfig{Interval: interval, Size: 3, MaxBytes: 1, Clock: clock})
+		_ = h.Record()
+		_ = r.Unregister("removed")
+		_ = r.Register(NewGauge("kept"))
+
+		// The single series of the budget stays taken until the points of
+		// the removed series have aged out of the span of a buffer.
+		clock.Advance(3 * interval)
+		_ = h.Record()
+		if got := h.Range("kept", nil, time.Time{}, clock.Now()); got != nil {
+			t.Fatalf("Range(kept) = %v, want nil while the budget is taken", got)
+		}
+
+		clock.Advance(interval)
+		_ = h.Record()
+		if got := h.Range("removed", nil, time.Time{}, clock.Now()); got != nil {
+			t.Errorf("Range(removed) = %v, want nil", got)
+		}
+		if got := h.Range("kept", nil, time.Time{}, clock.Now()); len(got) != 1 {
+			t.Errorf("Range(kept) = %v, want one point", got)
+		}
+	})
+
+	t.Run("clock going backwards restarts the series", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("g"))
+		h := NewHistory(r, HistoryConfig{Clock: clock})
+
+		clock.Advance(time.Minute)
+		_ = h.Record()
+		clock.Advance(-30 * time.Second)
+		_ = h.Record()
+
+		points := h.Range("g", nil, time.Time{}, clock.Now().Add(time.Hour))
+		if len(points) != 1 || !points[0].Time.Equal(clock.Now()) {
+			t.Errorf("Range() = %v, want one point at %v", points, clock.Now())
+		}
+	})
+
+	t.Run("records as a report loop sink", func(t *testing.T) {
+		clock := newFakeClock()
+		r := NewRegistry(0)
+		c := NewCounter("jobs_total")
+		_ = r.Register(c)
+		h := NewHistory(nil, HistoryConfig{Clock: clock})
+		loop, _ := NewReportLoop(r, ReportLoopConfig{Sinks: []ReportSink{{Name: "history", Reporter: h}}})
+
+		c.Add(3)
+		if err := loop.Flush(context.Background()); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+		points := h.Range("jobs_total", nil, clock.Now(), clock.Now())
+		if len(points) != 1 || points[0].Value != 3 {
+			t.Errorf("Range() = %v, want one point of 3", points)
+		}
+	})
+
+	t.Run("Run and Record without a registry return an error", func(t *testing.T) {
+		h := NewHistory(nil, HistoryConfig{})
+		if err := h.Run(context.Background()); err == nil {
+			t.Error("Run() error = nil, want error")
+		}
+		if err := h.Record(); err == nil {
+			t.Error("Record() error = nil, want error")
+		}
+	})
+}
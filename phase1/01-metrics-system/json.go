@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+// JSONContentType is the HTTP content type of the JSON format written by
+// WriteJSON.
+const JSONContentType = "application/json; charset=utf-8"
+
+// WriteJSON writes all metrics in r to w as a single JSON object keyed like
+// Registry.Snapshot. Counters and gauges are numbers; histograms, summaries
+// and meters are objects. NaN and infinite values, which JSON cannot
+// represent as numbers, are written as the strings "NaN", "+Inf" and "-Inf".
+// Metrics that cannot be collected are left out; the others are still
+// written, and the collection error is returned.
+func WriteJSON(w io.Writer, r *Registry) error {
+	samples, err := r.Collect()
+	return errors.Join(encodeJSON(w, samples), err)
+}
+
+// encodeJSON writes samples to w as a single JSON object.
+func encodeJSON(w io.Writer, samples []Sample) error {
+	out := make(map[string]interface{}, len(samples))
+	for _, s := range samples {
+		out[s.key()] = jsonValue(s)
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+// jsonBucket is the JSON form of a histogram bucket.
+type jsonBucket struct {
+	UpperBound interface{} `json:"le"`
+	Count      uint64      `json:"count"`
+}
+
+// jsonHistogram is the JSON form of a HistogramSnapshot.
+type jsonHistogram struct {
+	Buckets []jsonBucket `json:"buckets"`
+	Count   uint64       `json:"count"`
+	Sum     interface{}  `json:"sum"`
+}
+
+// jsonSummary is the JSON form of a SummarySnapshot.
+type jsonSummary struct {
+	Quantiles map[string]interface{} `json:"quantiles"`
+	Count     uint64                 `json:"count"`
+	Sum       interface{}            `json:"sum"`
+}
+
+// jsonMeter is the JSON form of a MeterSnapshot.
+type jsonMeter struct {
+	Count    int64       `json:"count"`
+	Rate1    interface{} `json:"rate_1m"`
+	Rate5    interface{} `json:"rate_5m"`
+	Rate15   interface{} `json:"rate_15m"`
+	MeanRate interface{} `json:"mean_rate"`
+}
+
+// jsonValue converts the reading of a sample into a JSON-encodable form.
+func jsonValue(s Sample) interface{} {
+	switch {
+	case s.Histogram != nil:
+		h := s.Histogram
+		buckets := make([]jsonBucket, 0, len(h.Buckets)+1)
+		for _, b := range h.Buckets {
+			buckets = append(buckets, jsonBucket{UpperBound: jsonFloat(b.UpperBound), Count: b.Count})
+		}
+		buckets = append(buckets, jsonBucket{UpperBound: "+Inf", Count: h.Count})
+		return jsonHistogram{Buckets: buckets, Count: h.Count, Sum: jsonFloat(h.Sum)}
+	case s.Summary != nil:
+		sum := s.Summary
+		quantiles := make(map[string]interface{}, len(sum.Quantiles))
+		for _, q := range sum.Quantiles {
+			quantiles[strconv.FormatFloat(q.Quantile, 'g', -1, 64)] = jsonFloat(q.Value)
+		}
+		return jsonSummary{Quantiles: quantiles, Count: sum.Count, Sum: jsonFloat(sum.Sum)}
+	case s.Meter != nil:
+		m := s.Meter
+		return jsonMeter{
+			Count:    m.Count,
+			Rate1:    jsonFloat(m.Rate1),
+			Rate5:    jsonFloat(m.Rate5),
+			Rate15:   jsonFloat(m.Rate15),
+			MeanRate: jsonFloat(m.MeanRate),
+		}
+	default:
+		if n, ok := s.integer(); ok {
+			return n
+		}
+		return jsonFloat(s.Value)
+	}
+}
+
+// jsonFloat returns v, or its Prometheus string form if it is not finite.
+func jsonFloat(v float64) interface{} {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return formatFloat(v)
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Labels is a set of label name/value pairs that distinguishes the children
+// of a metric vector, e.g. {"method": "GET", "status": "200"}.
+type Labels map[string]string
+
+// String renders the labels in Prometheus notation, sorted by label name,
+// e.g. {method="GET",status="200"}. It returns an empty string for an empty
+// set.
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range l.names() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(l[name]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// names returns the label names in sorted order.
+func (l Labels) names() []string {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clone returns a copy of the labels, or nil for an empty set.
+func (l Labels) clone() Labels {
+	if len(l) == 0 {
+		return nil
+	}
+	out := make(Labels, len(l))
+	for k, v := range l {
+		out[k] = v
+	}
+	return out
+}
+
+// checkLabelNames returns an error wrapping ErrInvalidLabelName if a
+// constant or variable label name of d is invalid.
+func checkLabelNames(d Desc) error {
+	seen := make(map[string]bool, len(d.VariableLabels))
+	for _, name := range d.VariableLabels {
+		if seen[name] {
+			return fmt.Errorf("%w: %s: %q is repeated", ErrInvalidLabelName, d.Name, name)
+		}
+		seen[name] = true
+	}
+	for _, name := range append(d.ConstLabels.names(), d.VariableLabels...) {
+		if reason := checkLabelName(name, d.Type); reason != "" {
+			return fmt.Errorf("%w: %s: %q %s", ErrInvalidLabelName, d.Name, name, reason)
+		}
+	}
+	return nil
+}
+
+// checkLabelName returns why name is not a valid label name for a metric
+// of type t, or "" if it is.
+func checkLabelName(name string, t MetricType) string {
+	if name == "" {
+		return "is empty"
+	}
+	for i, c := range name {
+		if c == ':' || !isNameChar(c, i == 0) {
+			return fmt.Sprintf("has character %q at offset %d, which is not allowed", c, i)
+		}
+	}
+	switch {
+	case strings.HasPrefix(name, "__"):
+		return "starts with __, which is reserved"
+	case t == TypeHistogram && name == "le":
+		return "is reserved for histogram buckets"
+	case t == TypeSummary && name == "quantile":
+		return "is reserved for summary quantiles"
+	}
+	return ""
+}
+
+// labelValueReplacer escapes label values as required by the Prometheus
+// text format.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+// metricKey returns the identifier used for a metric in snapshots: its name
+// followed by its labels, if any.
+func metricKey(name string, labels Labels) string {
+	return name + labels.String()
+}
@@ -0,0 +1,226 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// DefaultMeterTickInterval is the default interval at which the moving
+// averages of a Meter are updated.
+const DefaultMeterTickInterval = 5 * time.Second
+
+// meterWindows are the averaging windows of the rates of a Meter, in the
+// order of the rates array.
+var meterWindows = [...]time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// MeterConfig configures a Meter. The zero value selects the defaults.
+type MeterConfig struct {
+	// TickInterval is the interval at which the moving averages are
+	// updated. Events marked within an interval are averaged over it.
+	// Defaults to DefaultMeterTickInterval.
+	TickInterval time.Duration
+
+	// Clock is the time source of the moving averages.
+	// Defaults to the system clock.
+	Clock Clock
+}
+
+// MeterSnapshot is a point-in-time copy of a meter's state. Rates are in
+// events per second.
+type MeterSnapshot struct {
+	// Count is the number of events marked since the meter was created.
+	Count int64
+
+	// Rate1, Rate5 and Rate15 are the exponentially weighted moving
+	// average rates over 1, 5 and 15 minutes.
+	Rate1  float64
+	Rate5  float64
+	Rate15 float64
+
+	// MeanRate is the average rate since the meter was created.
+	MeanRate float64
+}
+
+// Meter is a metric that counts events and tracks their rate as
+// exponentially weighted moving averages over 1, 5 and 15 minutes, like
+// the load averages of Unix, along with the mean rate since creation.
+//
+// Marking events is lock-free. The averages are brought up to date when
+// the meter is read, every TickInterval of time elapsed since the last
+// read counting as one update, so a meter that is only read on scrapes
+// costs nothing in between.
+//
+// The JSON and Graphite exporters export the averages. The Prometheus,
+// OpenMetrics and StatsD exporters write a meter as a counter of its
+// events, from which their backends derive rates.
+//
+// A Meter is safe for concurrent use by multiple goroutines. The zero
+// value is ready to use with the default configuration.
+type Meter struct {
+	name      string
+	desc      *Desc
+	labels    Labels
+	created   time.Time
+	count     atomic.Int64
+	uncounted atomic.Int64 // events marked since the last tick
+	updateTracker
+
+	mu          sync.Mutex
+	cfg         MeterConfig
+	initialized bool
+	clock       Clock
+	interval    time.Duration
+	start       time.Time
+	lastTick    time.Time
+	rates       [len(meterWindows)]ewma
+}
+
+// Compile-time verification that Meter implements Metric interface.
+var _ Metric = (*Meter)(nil)
+
+// NewMeter creates a new meter metric with the given name, configuration
+// and options.
+func NewMeter(name string, cfg MeterConfig, opts ...Option) *Meter {
+	desc := newDesc(name, TypeMeter, nil, opts)
+	return &Meter{
+		name:    name,
+		desc:    &desc,
+		labels:  desc.ConstLabels,
+		created: clockOrDefault(cfg.Clock).Now(),
+		cfg:     cfg,
+	}
+}
+
+// Name returns the name of this meter metric.
+func (m *Meter) Name() string {
+	return m.name
+}
+
+// Desc returns the descriptor of this meter.
+func (m *Meter) Desc() Desc {
+	return describe(m.name, TypeMeter, m.desc)
+}
+
+// Labels returns a copy of the constant labels of this meter.
+func (m *Meter) Labels() Labels {
+	return m.labels.clone()
+}
+
+// Created returns the time the meter was created, or the zero time for a
+// zero-value Meter.
+func (m *Meter) Created() time.Time {
+	return m.created
+}
+
+// Type returns TypeMeter, indicating this is a meter metric.
+func (m *Meter) Type() MetricType {
+	return TypeMeter
+}
+
+// Value returns the current state of the meter as an interface{}.
+// The underlying type is MeterSnapshot.
+func (m *Meter) Value() interface{} {
+	return m.Snapshot()
+}
+
+// Mark records n events. Negative values are treated as 0.
+// This operation is atomic and safe for concurrent use.
+func (m *Meter) Mark(n int64) {
+	if n < 0 {
+		n = 0
+	}
+	m.count.Add(n)
+	m.uncounted.Add(n)
+	m.touch()
+}
+
+// Count returns the number of events marked since the meter was created.
+func (m *Meter) Count() int64 {
+	return m.count.Load()
+}
+
+// Snapshot returns the count and the current rates of the meter.
+func (m *Meter) Snapshot() MeterSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.initLocked()
+	now := m.clock.Now()
+	m.tickLocked(now)
+
+	snapshot := MeterSnapshot{
+		Count:  m.count.Load(),
+		Rate1:  m.rates[0].rate,
+		Rate5:  m.rates[1].rate,
+		Rate15: m.rates[2].rate,
+	}
+	if elapsed := now.Sub(m.start).Seconds(); elapsed > 0 {
+		snapshot.MeanRate = float64(snapshot.Count) / elapsed
+	}
+	return snapshot
+}
+
+// initLocked applies the configuration on first use. m.mu must be held.
+func (m *Meter) initLocked() {
+	if m.initialized {
+		return
+	}
+	m.initialized = true
+
+	m.clock = clockOrDefault(m.cfg.Clock)
+	m.interval = m.cfg.TickInterval
+	if m.interval <= 0 {
+		m.interval = DefaultMeterTickInterval
+	}
+
+	m.start = m.created
+	if m.start.IsZero() {
+		m.start = m.clock.Now()
+	}
+	m.lastTick = m.start
+
+	for i, window := range meterWindows {
+		m.rates[i].alpha = 1 - math.Exp(-m.interval.Seconds()/window.Seconds())
+	}
+}
+
+// tickLocked updates the moving averages for every tick interval elapsed
+// since the last update. Events marked since then are spread evenly over
+// the elapsed intervals. m.mu must be held.
+func (m *Meter) tickLocked(now time.Time) {
+	ticks := int64(now.Sub(m.lastTick) / m.interval)
+	if ticks <= 0 {
+		return
+	}
+	m.lastTick = m.lastTick.Add(time.Duration(ticks) * m.interval)
+
+	events := m.uncounted.Swap(0)
+	instant := float64(events) / (float64(ticks) * m.interval.Seconds())
+	for i := range m.rates {
+		m.rates[i].update(instant, ticks)
+	}
+}
+
+// ewma is an exponentially weighted moving average of a rate.
+type ewma struct {
+	alpha       float64 // weight of each new instant rate
+	rate        float64
+	initialized bool
+}
+
+// update applies ticks consecutive updates with the same instant rate. The
+// first update ever sets the rate outright, so that a new meter does not
+// start from zero.
+func (e *ewma) update(instant float64, ticks int64) {
+	if !e.initialized {
+		e.rate = instant
+		e.initialized = true
+		return
+	}
+	// After k updates r = i + (r - i)(1 - alpha)^k, computed directly so
+	// that a meter idle for days costs no more to read than a busy one.
+	e.rate = instant + (e.rate-instant)*math.Pow(1-e.alpha, float64(ticks))
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// approxEqual reports whether a and b are within a relative error of 1e-9.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// TestMeter tests counts and rates.
+func TestMeter(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMeter("jobs_total", MeterConfig{Clock: clock})
+
+	m.Mark(100)
+	clock.Advance(2 * time.Second)
+	got := m.Snapshot()
+	if got.Count != 100 || got.Rate1 != 0 || got.MeanRate != 50 {
+		t.Errorf("before first tick Snapshot() = %+v, want count 100, no rates, mean 50", got)
+	}
+
+	m.Mark(200)
+	m.Mark(-5) // ignored
+	clock.Advance(3 * time.Second)
+	got = m.Snapshot()
+	want := MeterSnapshot{Count: 300, Rate1: 60, Rate5: 60, Rate15: 60, MeanRate: 60}
+	if got != want {
+		t.Errorf("after first tick Snapshot() = %+v, want %+v", got, want)
+	}
+
+	// A minute without events decays each rate by e^(-1/window minutes).
+	clock.Advance(time.Minute)
+	got = m.Snapshot()
+	for _, r := range []struct {
+		name    string
+		got     float64
+		minutes float64
+	}{
+		{"Rate1", got.Rate1, 1},
+		{"Rate5", got.Rate5, 5},
+		{"Rate15", got.Rate15, 15},
+	} {
+		if want := 60 * math.Exp(-1/r.minutes); !approxEqual(r.got, want) {
+			t.Errorf("%s = %v, want %v", r.name, r.got, want)
+		}
+	}
+	if want := 300.0 / 65; !approxEqual(got.MeanRate, want) {
+		t.Errorf("MeanRate = %v, want %v", got.MeanRate, want)
+	}
+	if m.Count() != 300 {
+		t.Errorf("Count() = %d, want 300", m.Count())
+	}
+}
+
+// TestMeter_Convergence tests that the rates converge to a steady rate.
+func TestMeter_Convergence(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMeter("requests_total", MeterConfig{Clock: clock, TickInterval: time.Second})
+
+	m.Mark(100) // a burst, then 2 events per second for three hours
+	for i := 0; i < 3*60*60; i++ {
+		clock.Advance(time.Second)
+		m.Mark(2)
+		_ = m.Snapshot()
+	}
+
+	got := m.Snapshot()
+	for name, rate := range map[string]float64{"Rate1": got.Rate1, "Rate5": got.Rate5, "Rate15": got.Rate15} {
+		if math.Abs(rate-2) > 0.01 {
+			t.Errorf("%s = %v, want 2", name, rate)
+		}
+	}
+}
+
+// TestMeter_ReadFrequency tests that rates do not depend on how often the
+// meter is read when events are evenly spread.
+func TestMeter_ReadFrequency(t *testing.T) {
+	clock := newFakeClock()
+	often := NewMeter("often", MeterConfig{Clock: clock})
+	rarely := NewMeter("rarely", MeterConfig{Clock: clock})
+
+	// Initialize both with the same first tick.
+	often.Mark(50)
+	rarely.Mark(50)
+	clock.Advance(DefaultMeterTickInterval)
+	_, _ = often.Snapshot(), rarely.Snapshot()
+
+	for i := 0; i < 24; i++ {
+		clock.Advance(DefaultMeterTickInterval)
+		often.Mark(10)
+		rarely.Mark(10)
+		_ = often.Snapshot()
+	}
+
+	a, b := often.Snapshot(), rarely.Snapshot()
+	if !approxEqual(a.Rate1, b.Rate1) || !approxEqual(a.Rate5, b.Rate5) || !approxEqual(a.Rate15, b.Rate15) {
+		t.Errorf("rates read every tick = %+v, read once = %+v", a, b)
+	}
+}
+
+// TestMeter_ZeroValue tests that the zero value is usable.
+func TestMeter_ZeroValue(t *testing.T) {
+	var m Meter
+	m.Mark(3)
+
+	got := m.Snapshot()
+	if got.Count != 3 || got.Rate1 != 0 {
+		t.Errorf("Snapshot() = %+v, want count 3 and no rates", got)
+	}
+	if m.Type() != TypeMeter || m.Type().String() != "meter" {
+		t.Errorf("Type() = %v, want meter", m.Type())
+	}
+}
+
+// TestRegistry_Meter tests how meters are collected and exported.
+func TestRegistry_Meter(t *testing.T) {
+	clock := newFakeClock()
+	r := NewRegistry(0)
+	m := NewMeter("messages_total", MeterConfig{Clock: clock}, WithHelp("Messages consumed."))
+	if err := r.Register(m); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	m.Mark(50)
+	clock.Advance(5 * time.Second)
+
+	samples, err := r.Collect()
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(samples) != 1 || samples[0].Type != TypeMeter || samples[0].Meter == nil ||
+		samples[0].Meter.Rate1 != 10 {
+		t.Fatalf("Collect() = %+v, want one meter sample at 10/s", samples)
+	}
+
+	t.Run("prometheus", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WritePrometheus(&buf, r); err != nil {
+			t.Fatalf("WritePrometheus() error = %v", err)
+		}
+		want := `# HELP messages_total Messages consumed.
+# TYPE messages_total counter
+messages_total 50
+`
+		if got := buf.String(); got != want {
+			t.Errorf("output =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("openmetrics", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteOpenMetrics(&buf, r); err != nil {
+			t.Fatalf("WriteOpenMetrics() error = %v", err)
+		}
+		want := `# HELP messages Messages consumed.
+# TYPE messages counter
+messages_total 50
+messages_created 1.7040672e+09
+# EOF
+`
+		if got := buf.String(); got != want {
+			t.Errorf("output =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, r); err != nil {
+			t.Fatalf("WriteJSON() error = %v", err)
+		}
+		var got map[string]map[string]float64
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("unmarshal %s: %v", buf.String(), err)
+		}
+		want := map[string]float64{"count": 50, "rate_1m": 10, "rate_5m": 10, "rate_15m": 10, "mean_rate": 10}
+		for k, v := range want {
+			if got["messages_total"][k] != v {
+				t.Errorf("%s = %v, want %v in %s", k, got["messages_total"][k], v, strings.TrimSpace(buf.String()))
+			}
+		}
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		if _, ok := r.Snapshot()["messages_total"].(MeterSnapshot); !ok {
+			t.Errorf("Snapshot() = %v, want a MeterSnapshot", r.Snapshot())
+		}
+	})
+}
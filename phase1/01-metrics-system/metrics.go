@@ -1,5 +1,5 @@
 // Package metrics provides a thread-safe metrics collection system with
-// support for counters and gauges using atomic operations.
+// support for counters, gauges, histograms, summaries and meters.
 package metrics
 
 // Metric represents a metric that can be collected and reported.
@@ -26,6 +26,18 @@ const (
 
 	// TypeGauge represents a gauge metric that can increase or decrease.
 	TypeGauge
+
+	// TypeHistogram represents a histogram metric that samples observations
+	// into buckets.
+	TypeHistogram
+
+	// TypeSummary represents a summary metric that estimates quantiles of
+	// observations over a sliding time window.
+	TypeSummary
+
+	// TypeMeter represents a meter metric that counts events and tracks
+	// their moving average rates.
+	TypeMeter
 )
 
 // String returns a human-readable string representation of the metric type.
@@ -35,6 +47,12 @@ func (t MetricType) String() string {
 		return "counter"
 	case TypeGauge:
 		return "gauge"
+	case TypeHistogram:
+		return "histogram"
+	case TypeSummary:
+		return "summary"
+	case TypeMeter:
+		return "meter"
 	default:
 		return "unknown"
 	}
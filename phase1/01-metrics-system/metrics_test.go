@@ -23,6 +23,21 @@ func TestMetricType_String(t *testing.T) {
 			metricType: TypeGauge,
 			want:       "gauge",
 		},
+		{
+			name:       "histogram type",
+			metricType: TypeHistogram,
+			want:       "histogram",
+		},
+		{
+			name:       "summary type",
+			metricType: TypeSummary,
+			want:       "summary",
+		},
+		{
+			name:       "meter type",
+			metricType: TypeMeter,
+			want:       "meter",
+		},
 		{
 			name:       "unknown type",
 			metricType: MetricType(999),
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NamingPolicy selects the rules Registry.Register applies to metric
+// names. Empty names are always rejected; the zero value applies no other
+// rule, and is the policy of registries created without WithNamingPolicy.
+type NamingPolicy struct {
+	// Charset requires names to match the Prometheus data model,
+	// [a-zA-Z_:][a-zA-Z0-9_:]*.
+	Charset bool
+
+	// SnakeCase requires names to consist of lowercase words of letters
+	// and digits joined by single underscores, starting with a letter,
+	// e.g. http_requests_total.
+	SnakeCase bool
+
+	// ReservedSuffixes rejects names ending in _bucket, _sum or _count,
+	// which histograms and summaries generate, and names ending in _total
+	// unless the metric is a counter.
+	ReservedSuffixes bool
+
+	// MaxLength, if positive, is the maximum length of a name in bytes.
+	MaxLength int
+}
+
+// PrometheusNamingPolicy returns a policy that accepts every name the
+// Prometheus data model allows, except those that collide with series
+// generated by histograms, summaries and counters. Enable it with
+// WithNamingPolicy.
+func PrometheusNamingPolicy() NamingPolicy {
+	return NamingPolicy{
+		Charset:          true,
+		ReservedSuffixes: true,
+	}
+}
+
+// NamingRule identifies a rule of a NamingPolicy.
+type NamingRule int
+
+const (
+	// RuleNonEmpty requires a name to be non-empty. It is always applied.
+	RuleNonEmpty NamingRule = iota + 1
+
+	// RuleCharset is the rule enabled by NamingPolicy.Charset.
+	RuleCharset
+
+	// RuleSnakeCase is the rule enabled by NamingPolicy.SnakeCase.
+	RuleSnakeCase
+
+	// RuleReservedSuffix is the rule enabled by
+	// NamingPolicy.ReservedSuffixes.
+	RuleReservedSuffix
+
+	// RuleMaxLength is the rule enabled by NamingPolicy.MaxLength.
+	RuleMaxLength
+)
+
+// String returns the name of the rule, e.g. "snake_case".
+func (r NamingRule) String() string {
+	switch r {
+	case RuleNonEmpty:
+		return "non_empty"
+	case RuleCharset:
+		return "charset"
+	case RuleSnakeCase:
+		return "snake_case"
+	case RuleReservedSuffix:
+		return "reserved_suffix"
+	case RuleMaxLength:
+		return "max_length"
+	default:
+		return "unknown"
+	}
+}
+
+// NameError reports a metric name that violates a naming rule. It wraps
+// ErrInvalidMetricName, so errors.Is(err, ErrInvalidMetricName) holds.
+type NameError struct {
+	// Name is the rejected metric name.
+	Name string
+
+	// Rule is the rule the name violates.
+	Rule NamingRule
+
+	// Reason explains the violation.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *NameError) Error() string {
+	return fmt.Sprintf("invalid metric name %q: %s: %s", e.Name, e.Rule, e.Reason)
+}
+
+// Unwrap returns ErrInvalidMetricName.
+func (e *NameError) Unwrap() error {
+	return ErrInvalidMetricName
+}
+
+// reservedSuffixes are the suffixes of series generated from histograms
+// and summaries.
+var reservedSuffixes = []string{"_bucket", "_sum", "_count"}
+
+// validate checks the name of a metric of type t against the policy. It
+// returns a *NameError for the first rule the name violates.
+func (p NamingPolicy) validate(name string, t MetricType) error {
+	fail := func(rule NamingRule, format string, args ...interface{}) error {
+		return &NameError{Name: name, Rule: rule, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if name == "" {
+		return fail(RuleNonEmpty, "name is empty")
+	}
+
+	if p.MaxLength > 0 && len(name) > p.MaxLength {
+		return fail(RuleMaxLength, "name is %d bytes long, limit is %d", len(name), p.MaxLength)
+	}
+
+	if p.Charset {
+		for i, c := range name {
+			if !isNameChar(c, i == 0) {
+				return fail(RuleCharset, "character %q at offset %d is not allowed", c, i)
+			}
+		}
+	}
+
+	if p.SnakeCase {
+		if err := checkSnakeCase(name); err != "" {
+			return fail(RuleSnakeCase, "%s", err)
+		}
+	}
+
+	if p.ReservedSuffixes {
+		for _, suffix := range reservedSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				return fail(RuleReservedSuffix, "suffix %s is reserved for histograms and summaries", suffix)
+			}
+		}
+		if t != TypeCounter && t != TypeMeter && strings.HasSuffix(name, "_total") {
+			return fail(RuleReservedSuffix, "suffix _total is reserved for counters and meters, not %s", t)
+		}
+	}
+
+	return nil
+}
+
+// isNameChar reports whether c may appear in a Prometheus metric name.
+// Digits are not allowed as the first character.
+func isNameChar(c rune, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	default:
+		return false
+	}
+}
+
+// checkSnakeCase returns why name is not snake_case, or "" if it is.
+func checkSnakeCase(name string) string {
+	if c := name[0]; c < 'a' || c > 'z' {
+		return "name must start with a lowercase letter"
+	}
+	if strings.HasSuffix(name, "_") {
+		return "name must not end with an underscore"
+	}
+	if strings.Contains(name, "__") {
+		return "name must not contain consecutive underscores"
+	}
+	for i, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return fmt.Sprintf("character %q at offset %d is not a lowercase letter, digit or underscore", c, i)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestNamingPolicy tests metric name validation rules.
+func TestNamingPolicy(t *testing.T) {
+	strict := NamingPolicy{Charset: true, SnakeCase: true, ReservedSuffixes: true, MaxLength: 20}
+
+	tests := []struct {
+		name     string
+		policy   NamingPolicy
+		metric   string
+		typ      MetricType
+		wantRule NamingRule // zero if valid
+	}{
+		{name: "empty", policy: NamingPolicy{}, metric: "", typ: TypeGauge, wantRule: RuleNonEmpty},
+		{name: "zero policy accepts anything", policy: NamingPolicy{}, metric: "cpu temp-1", typ: TypeGauge},
+		{name: "charset accepts colons", policy: PrometheusNamingPolicy(), metric: "job:rate5m", typ: TypeGauge},
+		{name: "charset accepts uppercase", policy: PrometheusNamingPolicy(), metric: "HTTPRequests", typ: TypeGauge},
+		{name: "charset rejects space", policy: PrometheusNamingPolicy(), metric: "cpu temp", typ: TypeGauge, wantRule: RuleCharset},
+		{name: "charset rejects dash", policy: PrometheusNamingPolicy(), metric: "cpu-temp", typ: TypeGauge, wantRule: RuleCharset},
+		{name: "charset rejects dot", policy: PrometheusNamingPolicy(), metric: "cpu.temp", typ: TypeGauge, wantRule: RuleCharset},
+		{name: "charset rejects leading digit", policy: PrometheusNamingPolicy(), metric: "1xx_responses", typ: TypeGauge, wantRule: RuleCharset},
+		{name: "charset accepts inner digit", policy: PrometheusNamingPolicy(), metric: "http_2xx", typ: TypeGauge},
+		{name: "snake case accepts", policy: strict, metric: "http_requests_total", typ: TypeCounter},
+		{name: "snake case rejects uppercase", policy: strict, metric: "httpRequests", typ: TypeGauge, wantRule: RuleSnakeCase},
+		{name: "snake case rejects colon", policy: strict, metric: "job:rate", typ: TypeGauge, wantRule: RuleSnakeCase},
+		{name: "snake case rejects leading underscore", policy: strict, metric: "_private", typ: TypeGauge, wantRule: RuleSnakeCase},
+		{name: "snake case rejects trailing underscore", policy: strict, metric: "requests_", typ: TypeGauge, wantRule: RuleSnakeCase},
+		{name: "snake case rejects double underscore", policy: strict, metric: "http__requests", typ: TypeGauge, wantRule: RuleSnakeCase},
+		{name: "reserved _bucket", policy: PrometheusNamingPolicy(), metric: "latency_bucket", typ: TypeGauge, wantRule: RuleReservedSuffix},
+		{name: "reserved _sum", policy: PrometheusNamingPolicy(), metric: "latency_sum", typ: TypeCounter, wantRule: RuleReservedSuffix},
+		{name: "reserved _count", policy: PrometheusNamingPolicy(), metric: "latency_count", typ: TypeGauge, wantRule: RuleReservedSuffix},
+		{name: "_total allowed on counters", policy: PrometheusNamingPolicy(), metric: "jobs_total", typ: TypeCounter},
+		{name: "_total rejected on gauges", policy: PrometheusNamingPolicy(), metric: "jobs_total", typ: TypeGauge, wantRule: RuleReservedSuffix},
+		{name: "_total rejected on histograms", policy: PrometheusNamingPolicy(), metric: "jobs_total", typ: TypeHistogram, wantRule: RuleReservedSuffix},
+		{name: "max length", policy: strict, metric: strings.Repeat("a", 21), typ: TypeGauge, wantRule: RuleMaxLength},
+		{name: "at max length", policy: strict, metric: strings.Repeat("a", 20), typ: TypeGauge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.validate(tt.metric, tt.typ)
+			if tt.wantRule == 0 {
+				if err != nil {
+					t.Errorf("validate(%q) error = %v, want nil", tt.metric, err)
+				}
+				return
+			}
+
+			var nameErr *NameError
+			if !errors.As(err, &nameErr) {
+				t.Fatalf("validate(%q) error = %v, want *NameError", tt.metric, err)
+			}
+			if nameErr.Rule != tt.wantRule {
+				t.Errorf("validate(%q) rule = %v, want %v", tt.metric, nameErr.Rule, tt.wantRule)
+			}
+			if nameErr.Name != tt.metric {
+				t.Errorf("NameError.Name = %q, want %q", nameErr.Name, tt.metric)
+			}
+			if !errors.Is(err, ErrInvalidMetricName) {
+				t.Errorf("validate(%q) error does not wrap ErrInvalidMetricName", tt.metric)
+			}
+		})
+	}
+}
+
+// TestNamingRule_String tests the string form of naming rules.
+func TestNamingRule_String(t *testing.T) {
+	tests := []struct {
+		rule NamingRule
+		want string
+	}{
+		{RuleNonEmpty, "non_empty"},
+		{RuleCharset, "charset"},
+		{RuleSnakeCase, "snake_case"},
+		{RuleReservedSuffix, "reserved_suffix"},
+		{RuleMaxLength, "max_length"},
+		{NamingRule(0), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rule.String(); got != tt.want {
+			t.Errorf("NamingRule(%d).String() = %q, want %q", int(tt.rule), got, tt.want)
+		}
+	}
+}
+
+// TestRegistry_NamingPolicy tests that Register enforces the configured
+// naming policy.
+func TestRegistry_NamingPolicy(t *testing.T) {
+	t.Run("zero value only rejects empty names", func(t *testing.T) {
+		var r Registry
+
+		var nameErr *NameError
+		if err := r.Register(NewGauge("")); !errors.As(err, &nameErr) || nameErr.Rule != RuleNonEmpty {
+			t.Errorf("Register(empty) error = %v, want *NameError for rule non_empty", err)
+		}
+		for _, m := range []Metric{NewGauge("cpu temp"), NewCounter("latency_count")} {
+			if err := r.Register(m); err != nil {
+				t.Errorf("Register(%s) error = %v, want nil", m.Name(), err)
+			}
+		}
+	})
+
+	t.Run("configured policy", func(t *testing.T) {
+		r := NewRegistry(0, WithNamingPolicy(NamingPolicy{SnakeCase: true}))
+
+		if err := r.Register(NewGauge("cpuTemp")); !errors.Is(err, ErrInvalidMetricName) {
+			t.Errorf("Register(cpuTemp) error = %v, want ErrInvalidMetricName", err)
+		}
+		if err := r.Register(NewGauge("cpu_temp")); err != nil {
+			t.Errorf("Register(cpu_temp) error = %v, want nil", err)
+		}
+	})
+
+	t.Run("vectors are checked by family type", func(t *testing.T) {
+		r := NewRegistry(0, WithNamingPolicy(PrometheusNamingPolicy()))
+
+		if err := r.Register(NewCounterVec("requests_total", []string{"code"})); err != nil {
+			t.Errorf("Register(counter vec) error = %v, want nil", err)
+		}
+		if err := r.Register(NewGaugeVec("in_flight_total", []string{"code"})); !errors.Is(err, ErrInvalidMetricName) {
+			t.Errorf("Register(gauge vec) error = %v, want ErrInvalidMetricName", err)
+		}
+	})
+
+	t.Run("error message names the rule", func(t *testing.T) {
+		err := NewRegistry(0, WithNamingPolicy(PrometheusNamingPolicy())).Register(NewGauge("latency_sum"))
+		if err == nil || !strings.Contains(err.Error(), "reserved_suffix") {
+			t.Errorf("Register() error = %v, want mention of reserved_suffix", err)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenMetricsContentType is the HTTP content type of the OpenMetrics text
+// format written by WriteOpenMetrics.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// WriteOpenMetrics writes all metrics in r to w in the OpenMetrics 1.0
+// text format, terminated by # EOF.
+//
+// Counter families drop a trailing _total from their name and expose their
+// value as a _total sample. Meters are written as counters of their
+// events. Counters, histograms and summaries that know their creation time
+// also expose a _created sample. Exemplars recorded with
+// Counter.AddWithExemplar and Histogram.ObserveWithExemplar are attached to
+// the _total and _bucket samples. Units set with WithUnit are written as
+// # UNIT lines when the family name ends with the unit, as OpenMetrics
+// requires.
+//
+// Metrics that cannot be collected are left out; the others are still
+// written, and the collection error is returned.
+func WriteOpenMetrics(w io.Writer, r *Registry) error {
+	samples, err := r.Collect()
+	return errors.Join(encodeOpenMetrics(w, samples), err)
+}
+
+// encodeOpenMetrics writes samples, sorted by name, to w in the OpenMetrics
+// text format.
+func encodeOpenMetrics(w io.Writer, samples []Sample) error {
+	bw := bufio.NewWriter(w)
+	enc := openMetricsEncoder{w: bw}
+
+	for i, s := range samples {
+		if i == 0 || s.Name != samples[i-1].Name {
+			enc.writeHeader(s)
+		}
+		enc.writeMetric(s)
+	}
+	bw.WriteString("# EOF\n")
+
+	return bw.Flush()
+}
+
+// openMetricsEncoder writes the OpenMetrics text format.
+type openMetricsEncoder struct {
+	w *bufio.Writer
+}
+
+// familyName returns the OpenMetrics family name of s, which for counters
+// and meters excludes the _total suffix.
+func familyName(s Sample) string {
+	if s.Type == TypeCounter || s.Type == TypeMeter {
+		return strings.TrimSuffix(s.Name, "_total")
+	}
+	return s.Name
+}
+
+// writeHeader writes the # HELP and # TYPE lines of the family of s.
+func (e *openMetricsEncoder) writeHeader(s Sample) {
+	name := familyName(s)
+	if s.Help != "" {
+		fmt.Fprintf(e.w, "# HELP %s %s\n", name, escapeOpenMetricsHelp(s.Help))
+	}
+	fmt.Fprintf(e.w, "# TYPE %s %s\n", name, openMetricsType(s.Type))
+	if s.Unit != "" && strings.HasSuffix(name, "_"+s.Unit) {
+		fmt.Fprintf(e.w, "# UNIT %s %s\n", name, s.Unit)
+	}
+}
+
+func (e *openMetricsEncoder) writeMetric(s Sample) {
+	name, labels := familyName(s), s.Labels
+
+	switch {
+	case s.Histogram != nil:
+		h := s.Histogram
+		for _, b := range h.Buckets {
+			e.writeSample(name+"_bucket", labels, "le", formatOpenMetricsFloat(b.UpperBound), formatUint(b.Count), b.Exemplar)
+		}
+		e.writeSample(name+"_bucket", labels, "le", "+Inf", formatUint(h.Count), h.InfExemplar)
+		e.writeSample(name+"_count", labels, "", "", formatUint(h.Count), nil)
+		e.writeSample(name+"_sum", labels, "", "", formatOpenMetricsFloat(h.Sum), nil)
+	case s.Summary != nil:
+		sum := s.Summary
+		for _, q := range sum.Quantiles {
+			e.writeSample(name, labels, "quantile", formatOpenMetricsFloat(q.Quantile), formatOpenMetricsFloat(q.Value), nil)
+		}
+		e.writeSample(name+"_count", labels, "", "", formatUint(sum.Count), nil)
+		e.writeSample(name+"_sum", labels, "", "", formatOpenMetricsFloat(sum.Sum), nil)
+	case s.Meter != nil:
+		e.writeSample(name+"_total", labels, "", "", strconv.FormatInt(s.Meter.Count, 10), nil)
+	case s.Type == TypeCounter:
+		e.writeSample(name+"_total", labels, "", "", formatOpenMetricsValue(s), s.Exemplar)
+	default:
+		e.writeSample(name, labels, "", "", formatOpenMetricsValue(s), nil)
+		return
+	}
+
+	if !s.Created.IsZero() {
+		e.writeSample(name+"_created", labels, "", "", formatTimestamp(s.Created), nil)
+	}
+}
+
+// writeSample writes a single sample line with an optional extra label and
+// an optional exemplar.
+func (e *openMetricsEncoder) writeSample(name string, labels Labels, extraName, extraValue, value string, exemplar *Exemplar) {
+	e.w.WriteString(name)
+	writeLabels(e.w, labels, extraName, extraValue)
+	e.w.WriteByte(' ')
+	e.w.WriteString(value)
+
+	if exemplar != nil {
+		e.w.WriteString(" # ")
+		writeLabels(e.w, exemplar.Labels, "", "")
+		e.w.WriteByte(' ')
+		e.w.WriteString(formatOpenMetricsFloat(exemplar.Value))
+		if !exemplar.Timestamp.IsZero() {
+			e.w.WriteByte(' ')
+			e.w.WriteString(formatTimestamp(exemplar.Timestamp))
+		}
+	}
+	e.w.WriteByte('\n')
+}
+
+// openMetricsType returns the # TYPE name for a metric type.
+func openMetricsType(t MetricType) string {
+	switch t {
+	case TypeCounter, TypeGauge, TypeHistogram, TypeSummary:
+		return t.String()
+	case TypeMeter:
+		return TypeCounter.String()
+	default:
+		return "unknown"
+	}
+}
+
+// openMetricsHelpReplacer escapes help text as required by OpenMetrics,
+// which unlike the Prometheus format also escapes double quotes.
+var openMetricsHelpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeOpenMetricsHelp(help string) string {
+	return openMetricsHelpReplacer.Replace(help)
+}
+
+// formatOpenMetricsValue formats the value of a counter or gauge sample,
+// keeping integral counters as integers.
+func formatOpenMetricsValue(s Sample) string {
+	if n, ok := s.integer(); ok {
+		return strconv.FormatInt(n, 10)
+	}
+	return formatOpenMetricsFloat(s.Value)
+}
+
+// formatOpenMetricsFloat formats a float in the canonical OpenMetrics form,
+// in which integral values keep a ".0" suffix, e.g. le="1.0".
+func formatOpenMetricsFloat(v float64) string {
+	s := formatFloat(v)
+	if strings.ContainsAny(s, ".eIN") {
+		return s
+	}
+	return s + ".0"
+}
+
+// formatTimestamp formats t as seconds since the Unix epoch.
+func formatTimestamp(t time.Time) string {
+	return formatOpenMetricsFloat(float64(t.UnixNano()) / 1e9)
+}
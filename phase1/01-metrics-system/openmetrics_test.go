@@ -0,0 +1,232 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCounter_AddWithExemplar tests exemplar recording on counters.
+func TestCounter_AddWithExemplar(t *testing.T) {
+	t.Run("records latest exemplar", func(t *testing.T) {
+		c := NewCounter("test")
+
+		if _, ok := c.Exemplar(); ok {
+			t.Error("new Counter has an exemplar")
+		}
+
+		if err := c.AddWithExemplar(2, Labels{"trace_id": "abc"}); err != nil {
+			t.Fatalf("AddWithExemplar() error = %v", err)
+		}
+		if err := c.AddWithExemplar(3, Labels{"trace_id": "def"}); err != nil {
+			t.Fatalf("AddWithExemplar() error = %v", err)
+		}
+
+		if got := c.Load(); got != 5 {
+			t.Errorf("Counter.Load() = %v, want 5", got)
+		}
+
+		e, ok := c.Exemplar()
+		if !ok {
+			t.Fatal("Counter.Exemplar() returned false, want true")
+		}
+		if e.Labels["trace_id"] != "def" || e.Value != 3 || e.Timestamp.IsZero() {
+			t.Errorf("Counter.Exemplar() = %+v, want trace_id=def value=3 with timestamp", e)
+		}
+	})
+
+	t.Run("returned exemplar is a copy", func(t *testing.T) {
+		c := NewCounter("test")
+		_ = c.AddWithExemplar(1, Labels{"trace_id": "abc"})
+
+		e, _ := c.Exemplar()
+		e.Labels["trace_id"] = "mutated"
+
+		if got, _ := c.Exemplar(); got.Labels["trace_id"] != "abc" {
+			t.Errorf("recorded exemplar trace_id = %q, want abc", got.Labels["trace_id"])
+		}
+	})
+
+	t.Run("rejects invalid exemplars but still counts", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			labels Labels
+		}{
+			{name: "no labels", labels: nil},
+			{name: "empty label name", labels: Labels{"": "x"}},
+			{name: "too long", labels: Labels{"trace_id": strings.Repeat("x", 121)}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				c := NewCounter("test")
+
+				if err := c.AddWithExemplar(1, tt.labels); !errors.Is(err, ErrInvalidExemplar) {
+					t.Errorf("AddWithExemplar() error = %v, want ErrInvalidExemplar", err)
+				}
+				if got := c.Load(); got != 1 {
+					t.Errorf("Counter.Load() = %v, want 1", got)
+				}
+				if _, ok := c.Exemplar(); ok {
+					t.Error("invalid exemplar was recorded")
+				}
+			})
+		}
+	})
+}
+
+// TestHistogram_ObserveWithExemplar tests exemplar recording on histograms.
+func TestHistogram_ObserveWithExemplar(t *testing.T) {
+	h := NewHistogram("test", []float64{1})
+
+	if err := h.ObserveWithExemplar(0.5, Labels{"trace_id": "low"}); err != nil {
+		t.Fatalf("ObserveWithExemplar() error = %v", err)
+	}
+	if err := h.ObserveWithExemplar(5, Labels{"trace_id": "high"}); err != nil {
+		t.Fatalf("ObserveWithExemplar() error = %v", err)
+	}
+
+	got := h.Snapshot()
+	if got.Count != 2 {
+		t.Errorf("Histogram count = %v, want 2", got.Count)
+	}
+	if e := got.Buckets[0].Exemplar; e == nil || e.Labels["trace_id"] != "low" || e.Value != 0.5 {
+		t.Errorf("bucket le=1 exemplar = %+v, want trace_id=low value=0.5", e)
+	}
+	if e := got.InfExemplar; e == nil || e.Labels["trace_id"] != "high" {
+		t.Errorf("+Inf exemplar = %+v, want trace_id=high", e)
+	}
+
+	// Snapshots hold copies of the recorded exemplars.
+	got.Buckets[0].Exemplar.Labels["trace_id"] = "mutated"
+	got.InfExemplar.Value = -1
+	again := h.Snapshot()
+	if e := again.Buckets[0].Exemplar; e.Labels["trace_id"] != "low" {
+		t.Errorf("bucket le=1 exemplar after mutating a snapshot = %+v, want trace_id=low", e)
+	}
+	if e := again.InfExemplar; e.Value != 5 {
+		t.Errorf("+Inf exemplar after mutating a snapshot = %+v, want value 5", e)
+	}
+
+	var zero Histogram
+	if err := zero.ObserveWithExemplar(1, Labels{"trace_id": "z"}); err != nil {
+		t.Fatalf("zero value ObserveWithExemplar() error = %v", err)
+	}
+	if zero.Snapshot().InfExemplar == nil {
+		t.Error("zero value Histogram did not record +Inf exemplar")
+	}
+}
+
+// TestWriteOpenMetrics tests the OpenMetrics text encoder.
+func TestWriteOpenMetrics(t *testing.T) {
+	created := time.Unix(1700000000, 500000000)
+	exemplarTime := time.Unix(1700000100, 0)
+
+	r := NewRegistry(0)
+
+	requests := NewCounterVec("http_requests_total", []string{"code"})
+	ok, _ := requests.WithLabelValues("200")
+	ok.created = created
+	ok.Add(7)
+	ok.exemplar.Store(&Exemplar{Labels: Labels{"trace_id": "abc"}, Value: 1, Timestamp: exemplarTime})
+
+	var jobs Counter
+	jobs.name = "jobs"
+	jobs.Add(2)
+
+	queue := helpfulGauge{Gauge: NewGauge("queue_depth"), help: `Jobs "waiting".`}
+	queue.Set(4)
+
+	latency := NewHistogram("latency_seconds", []float64{1})
+	latency.created = created
+	latency.Observe(2)
+	latency.exemplars[0].Store(&Exemplar{Labels: Labels{"trace_id": "def"}, Value: 0.5})
+
+	for _, m := range []Metric{requests, &jobs, queue, latency} {
+		if err := r.Register(m); err != nil {
+			t.Fatalf("Register(%s) failed: %v", m.Name(), err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, r); err != nil {
+		t.Fatalf("WriteOpenMetrics() error = %v", err)
+	}
+
+	want := strings.Join([]string{
+		`# TYPE http_requests counter`,
+		`http_requests_total{code="200"} 7 # {trace_id="abc"} 1.0 1.7000001e+09`,
+		`http_requests_created{code="200"} 1.7000000005e+09`,
+		`# TYPE jobs counter`,
+		`jobs_total 2`,
+		`# TYPE latency_seconds histogram`,
+		`latency_seconds_bucket{le="1.0"} 0 # {trace_id="def"} 0.5`,
+		`latency_seconds_bucket{le="+Inf"} 1`,
+		`latency_seconds_count 1`,
+		`latency_seconds_sum 2.0`,
+		`latency_seconds_created 1.7000000005e+09`,
+		`# HELP queue_depth Jobs \"waiting\".`,
+		`# TYPE queue_depth gauge`,
+		`queue_depth 4.0`,
+		`# EOF`,
+		``,
+	}, "\n")
+
+	if got := buf.String(); got != want {
+		t.Errorf("WriteOpenMetrics() output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestWriteOpenMetrics_Summary tests summary encoding in OpenMetrics.
+func TestWriteOpenMetrics_Summary(t *testing.T) {
+	r := NewRegistry(0)
+	s := NewSummary("rpc_seconds", SummaryConfig{Objectives: map[float64]float64{0.5: 0.05}})
+	s.created = time.Time{}
+	s.Observe(3)
+	_ = r.Register(s)
+
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, r); err != nil {
+		t.Fatalf("WriteOpenMetrics() error = %v", err)
+	}
+
+	want := strings.Join([]string{
+		`# TYPE rpc_seconds summary`,
+		`rpc_seconds{quantile="0.5"} 3.0`,
+		`rpc_seconds_count 1`,
+		`rpc_seconds_sum 3.0`,
+		`# EOF`,
+		``,
+	}, "\n")
+
+	if got := buf.String(); got != want {
+		t.Errorf("WriteOpenMetrics() output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestWriteOpenMetrics_Errors tests error handling in the OpenMetrics encoder.
+func TestWriteOpenMetrics_Errors(t *testing.T) {
+	t.Run("rejects unsupported values and writes the rest", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(unsupportedMetric{})
+		_ = r.Register(NewGauge("healthy"))
+
+		var buf bytes.Buffer
+		if err := WriteOpenMetrics(&buf, r); err == nil {
+			t.Error("WriteOpenMetrics() with unsupported value should return error")
+		}
+		if want := "healthy 0.0\n# EOF\n"; !strings.HasSuffix(buf.String(), want) {
+			t.Errorf("WriteOpenMetrics() = %q, want it to end with %q", buf.String(), want)
+		}
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		var r Registry
+
+		if err := WriteOpenMetrics(errWriter{}, &r); err == nil {
+			t.Error("WriteOpenMetrics() to failing writer should return error")
+		}
+	})
+}
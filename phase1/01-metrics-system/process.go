@@ -0,0 +1,338 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultProcFS is the default mount point of the proc filesystem.
+	DefaultProcFS = "/proc"
+
+	// clockTicks is the number of clock ticks per second in which the
+	// kernel reports CPU times (USER_HZ). It is 100 on every Linux
+	// architecture Go supports.
+	clockTicks = 100
+)
+
+// Names of the metrics exported by ProcessCollector.
+const (
+	processCPUSeconds     = "process_cpu_seconds_total"
+	processResidentMemory = "process_resident_memory_bytes"
+	processVirtualMemory  = "process_virtual_memory_bytes"
+	processOpenFDs        = "process_open_fds"
+	processMaxFDs         = "process_max_fds"
+	processStartTime      = "process_start_time_seconds"
+)
+
+// processDescs describes the metrics exported by ProcessCollector.
+var processDescs = []Desc{
+	{
+		Name: processCPUSeconds,
+		Type: TypeCounter,
+		Help: "Total user and system CPU time spent in seconds.",
+		Unit: "seconds",
+	},
+	{
+		Name: processResidentMemory,
+		Type: TypeGauge,
+		Help: "Resident memory size in bytes.",
+		Unit: "bytes",
+	},
+	{
+		Name: processVirtualMemory,
+		Type: TypeGauge,
+		Help: "Virtual memory size in bytes.",
+		Unit: "bytes",
+	},
+	{
+		Name: processOpenFDs,
+		Type: TypeGauge,
+		Help: "Number of open file descriptors.",
+	},
+	{
+		Name: processMaxFDs,
+		Type: TypeGauge,
+		Help: "Maximum number of open file descriptors.",
+	},
+	{
+		Name: processStartTime,
+		Type: TypeGauge,
+		Help: "Start time of the process since the Unix epoch in seconds.",
+		Unit: "seconds",
+	},
+}
+
+// ProcessCollector is a Collector that exports the resource usage of the
+// current process, read from the Linux proc filesystem:
+//
+//	process_cpu_seconds_total      counter  /proc/self/stat
+//	process_resident_memory_bytes  gauge    /proc/self/status
+//	process_virtual_memory_bytes   gauge    /proc/self/status
+//	process_open_fds               gauge    /proc/self/fd
+//	process_max_fds                gauge    /proc/self/limits
+//	process_start_time_seconds     gauge    /proc/self/stat and /proc/stat
+//
+// An unlimited file descriptor limit is reported as +Inf. On systems
+// without a proc filesystem, Collect returns an error.
+//
+// It is safe for concurrent use.
+type ProcessCollector struct {
+	procfs string
+}
+
+// Compile-time verification that ProcessCollector implements Collector.
+var _ Collector = (*ProcessCollector)(nil)
+
+// ProcessCollectorOption configures a ProcessCollector.
+type ProcessCollectorOption interface {
+	applyProcess(*ProcessCollector)
+}
+
+type procFSOption string
+
+func (o procFSOption) applyProcess(c *ProcessCollector) {
+	c.procfs = string(o)
+}
+
+// WithProcFS sets the mount point of the proc filesystem the collector
+// reads, e.g. a fixture directory in tests. Without it, DefaultProcFS
+// applies.
+func WithProcFS(root string) ProcessCollectorOption {
+	return procFSOption(root)
+}
+
+// NewProcessCollector creates a collector for the current process.
+func NewProcessCollector(opts ...ProcessCollectorOption) *ProcessCollector {
+	c := &ProcessCollector{procfs: DefaultProcFS}
+	for _, opt := range opts {
+		opt.applyProcess(c)
+	}
+	return c
+}
+
+// RegisterProcessCollector registers a ProcessCollector in r.
+func RegisterProcessCollector(r *Registry, opts ...ProcessCollectorOption) error {
+	return r.RegisterCollector(NewProcessCollector(opts...))
+}
+
+// Describe returns the descriptors of the process metrics.
+func (c *ProcessCollector) Describe() []Desc {
+	descs := make([]Desc, len(processDescs))
+	for i, d := range processDescs {
+		descs[i] = d.clone()
+	}
+	return descs
+}
+
+// Collect reads the current resource usage of the process. If a source
+// cannot be read or parsed, it returns the other samples and an error.
+func (c *ProcessCollector) Collect(context.Context) ([]Sample, error) {
+	var (
+		samples []Sample
+		errs    []error
+	)
+	add := func(name string, value float64) {
+		samples = append(samples, Sample{Name: name, Value: value})
+	}
+
+	if stat, err := c.readStat(); err != nil {
+		errs = append(errs, err)
+	} else {
+		add(processCPUSeconds, stat.cpuSeconds())
+		if bootTime, err := c.readBootTime(); err != nil {
+			errs = append(errs, err)
+		} else {
+			add(processStartTime, bootTime+stat.startTime())
+		}
+	}
+
+	if rss, vms, err := c.readMemory(); err != nil {
+		errs = append(errs, err)
+	} else {
+		add(processResidentMemory, rss)
+		add(processVirtualMemory, vms)
+	}
+
+	if fds, err := c.countOpenFDs(); err != nil {
+		errs = append(errs, err)
+	} else {
+		add(processOpenFDs, fds)
+	}
+
+	if maxFDs, err := c.readMaxFDs(); err != nil {
+		errs = append(errs, err)
+	} else {
+		add(processMaxFDs, maxFDs)
+	}
+
+	return samples, errors.Join(errs...)
+}
+
+// path returns the path of a file of the proc filesystem.
+func (c *ProcessCollector) path(elem ...string) string {
+	return filepath.Join(append([]string{c.procfs}, elem...)...)
+}
+
+// procStat holds the fields of /proc/self/stat the collector uses, in
+// clock ticks.
+type procStat struct {
+	utime     uint64
+	stime     uint64
+	starttime uint64 // since boot
+}
+
+// cpuSeconds returns the user and system CPU time in seconds.
+func (s procStat) cpuSeconds() float64 {
+	return float64(s.utime+s.stime) / clockTicks
+}
+
+// startTime returns the start time of the process in seconds since boot.
+func (s procStat) startTime() float64 {
+	return float64(s.starttime) / clockTicks
+}
+
+// readStat parses /proc/self/stat. See proc(5) for its format.
+func (c *ProcessCollector) readStat() (procStat, error) {
+	path := c.path("self", "stat")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return procStat{}, err
+	}
+
+	// The command name in field 2 is parenthesized and may itself contain
+	// spaces and parentheses, so fields are counted from the last ')'.
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}, fmt.Errorf("parse %s: no command name", path)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+
+	// fields[0] is field 3 (state); utime, stime and starttime are fields
+	// 14, 15 and 22.
+	const utime, stime, starttime = 14 - 3, 15 - 3, 22 - 3
+	if len(fields) <= starttime {
+		return procStat{}, fmt.Errorf("parse %s: got %d fields", path, len(fields)+2)
+	}
+
+	var s procStat
+	for _, f := range []struct {
+		dst *uint64
+		i   int
+	}{
+		{&s.utime, utime},
+		{&s.stime, stime},
+		{&s.starttime, starttime},
+	} {
+		if *f.dst, err = strconv.ParseUint(fields[f.i], 10, 64); err != nil {
+			return procStat{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// readBootTime returns the boot time in seconds since the Unix epoch, from
+// the btime line of /proc/stat.
+func (c *ProcessCollector) readBootTime() (float64, error) {
+	path := c.path("stat")
+	value, err := scanField(path, "btime")
+	if err != nil {
+		return 0, err
+	}
+
+	btime, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return float64(btime), nil
+}
+
+// readMemory returns the resident and virtual memory sizes in bytes, from
+// the VmRSS and VmSize lines of /proc/self/status.
+func (c *ProcessCollector) readMemory() (rss, vms float64, err error) {
+	path := c.path("self", "status")
+	if rss, err = scanKilobytes(path, "VmRSS:"); err != nil {
+		return 0, 0, err
+	}
+	if vms, err = scanKilobytes(path, "VmSize:"); err != nil {
+		return 0, 0, err
+	}
+	return rss, vms, nil
+}
+
+// countOpenFDs returns the number of entries in /proc/self/fd.
+func (c *ProcessCollector) countOpenFDs() (float64, error) {
+	entries, err := os.ReadDir(c.path("self", "fd"))
+	if err != nil {
+		return 0, err
+	}
+	return float64(len(entries)), nil
+}
+
+// readMaxFDs returns the soft limit on open files, from the "Max open
+// files" line of /proc/self/limits.
+func (c *ProcessCollector) readMaxFDs() (float64, error) {
+	path := c.path("self", "limits")
+	value, err := scanField(path, "Max open files")
+	if err != nil {
+		return 0, err
+	}
+
+	soft := strings.Fields(value)
+	if len(soft) == 0 {
+		return 0, fmt.Errorf("parse %s: no limit for open files", path)
+	}
+	if soft[0] == "unlimited" {
+		return math.Inf(1), nil
+	}
+
+	limit, err := strconv.ParseUint(soft[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return float64(limit), nil
+}
+
+// scanKilobytes returns the value of a "Key: N kB" line of a status file in
+// bytes.
+func scanKilobytes(path, key string) (float64, error) {
+	value, err := scanField(path, key)
+	if err != nil {
+		return 0, err
+	}
+
+	kb, err := strconv.ParseUint(strings.TrimSuffix(value, " kB"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return float64(kb) * 1024, nil
+}
+
+// scanField returns the rest of the first line of the file at path that
+// starts with key, with surrounding white space removed.
+func scanField(path, key string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), key); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return "", fmt.Errorf("parse %s: no %q line", path, key)
+}
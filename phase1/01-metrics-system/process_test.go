@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixtureProcFS is a proc filesystem snapshot of a process named
+// "my (odd) app", whose name exercises the parsing of /proc/self/stat.
+const fixtureProcFS = "testdata/proc"
+
+// copyProcFS copies the fixture to a temporary directory that a test may
+// modify.
+func copyProcFS(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	if err := os.CopyFS(root, os.DirFS(fixtureProcFS)); err != nil {
+		t.Fatalf("copy fixture: %v", err)
+	}
+	return root
+}
+
+// collectProcess collects a process collector reading root into a map.
+func collectProcess(t *testing.T, root string) (map[string]float64, error) {
+	t.Helper()
+
+	samples, err := NewProcessCollector(WithProcFS(root)).Collect(context.Background())
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		values[s.Name] = s.Value
+	}
+	return values, err
+}
+
+// TestProcessCollector tests reading the fixture.
+func TestProcessCollector(t *testing.T) {
+	got, err := collectProcess(t, fixtureProcFS)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	want := map[string]float64{
+		"process_cpu_seconds_total":     18,         // (1234 + 566) ticks
+		"process_start_time_seconds":    1700001800, // btime + 180000 ticks
+		"process_resident_memory_bytes": 48000 * 1024,
+		"process_virtual_memory_bytes":  716800 * 1024,
+		"process_open_fds":              5,
+		"process_max_fds":               1024,
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d samples, want %d: %v", len(got), len(want), got)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %v, want %v", name, got[name], value)
+		}
+	}
+}
+
+// TestProcessCollector_Registry tests the samples seen through a registry.
+func TestProcessCollector_Registry(t *testing.T) {
+	r := NewRegistry(0)
+	if err := RegisterProcessCollector(r, WithProcFS(fixtureProcFS)); err != nil {
+		t.Fatalf("RegisterProcessCollector() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, r); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+
+	want := `# HELP process_cpu_seconds_total Total user and system CPU time spent in seconds.
+# TYPE process_cpu_seconds_total counter
+process_cpu_seconds_total 18
+# HELP process_max_fds Maximum number of open file descriptors.
+# TYPE process_max_fds gauge
+process_max_fds 1024
+# HELP process_open_fds Number of open file descriptors.
+# TYPE process_open_fds gauge
+process_open_fds 5
+# HELP process_resident_memory_bytes Resident memory size in bytes.
+# TYPE process_resident_memory_bytes gauge
+process_resident_memory_bytes 4.9152e+07
+# HELP process_start_time_seconds Start time of the process since the Unix epoch in seconds.
+# TYPE process_start_time_seconds gauge
+process_start_time_seconds 1.7000018e+09
+# HELP process_virtual_memory_bytes Virtual memory size in bytes.
+# TYPE process_virtual_memory_bytes gauge
+process_virtual_memory_bytes 7.340032e+08
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestProcessCollector_Errors tests missing and malformed sources.
+func TestProcessCollector_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string // empty removes the file
+		missing []string
+	}{
+		{
+			name:    "missing stat",
+			file:    "self/stat",
+			missing: []string{"process_cpu_seconds_total", "process_start_time_seconds"},
+		},
+		{
+			name:    "truncated stat",
+			file:    "self/stat",
+			content: "4242 (app) S 1 4242\n",
+			missing: []string{"process_cpu_seconds_total", "process_start_time_seconds"},
+		},
+		{
+			name:    "missing boot time",
+			file:    "stat",
+			content: "cpu  1 2 3 4\n",
+			missing: []string{"process_start_time_seconds"},
+		},
+		{
+			name:    "missing status",
+			file:    "self/status",
+			missing: []string{"process_resident_memory_bytes", "process_virtual_memory_bytes"},
+		},
+		{
+			name:    "malformed status",
+			file:    "self/status",
+			content: "VmSize:\t716800 kB\nVmRSS:\tmany kB\n",
+			missing: []string{"process_resident_memory_bytes", "process_virtual_memory_bytes"},
+		},
+		{
+			name:    "missing limits",
+			file:    "self/limits",
+			missing: []string{"process_max_fds"},
+		},
+		{
+			name:    "missing fd",
+			file:    "self/fd",
+			missing: []string{"process_open_fds"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := copyProcFS(t)
+			path := filepath.Join(root, tt.file)
+			if err := os.RemoveAll(path); err != nil {
+				t.Fatal(err)
+			}
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := collectProcess(t, root)
+			if err == nil {
+				t.Error("Collect() succeeded, want error")
+			}
+			if tt.content == "" && !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Collect() error = %v, want fs.ErrNotExist", err)
+			}
+			for _, name := range tt.missing {
+				if _, ok := got[name]; ok {
+					t.Errorf("%s collected despite error", name)
+				}
+			}
+			if len(got) != 6-len(tt.missing) {
+				t.Errorf("got %d samples, want %d", len(got), 6-len(tt.missing))
+			}
+		})
+	}
+}
+
+// TestProcessCollector_UnlimitedFDs tests an unlimited open file limit.
+func TestProcessCollector_UnlimitedFDs(t *testing.T) {
+	root := copyProcFS(t)
+	limits := "Limit                     Soft Limit           Hard Limit           Units     \n" +
+		"Max open files            unlimited            unlimited            files     \n"
+	if err := os.WriteFile(filepath.Join(root, "self", "limits"), []byte(limits), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := collectProcess(t, root)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if !math.IsInf(got["process_max_fds"], 1) {
+		t.Errorf("process_max_fds = %v, want +Inf", got["process_max_fds"])
+	}
+}
+
+// TestProcessCollector_Self tests reading the proc filesystem of the test
+// process itself, where there is one.
+func TestProcessCollector_Self(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no proc filesystem")
+	}
+
+	got, err := collectProcess(t, DefaultProcFS)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	for _, name := range []string{
+		"process_resident_memory_bytes",
+		"process_virtual_memory_bytes",
+		"process_open_fds",
+		"process_max_fds",
+		"process_start_time_seconds",
+	} {
+		if got[name] <= 0 {
+			t.Errorf("%s = %v, want > 0", name, got[name])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the HTTP content type of the Prometheus text
+// exposition format written by WritePrometheus.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus writes all metrics in r to w in the Prometheus text
+// exposition format, version 0.0.4.
+//
+// Metric families are written in name order, each preceded by its # HELP
+// (when help text is available) and # TYPE lines. Children of vectors are
+// written in label order. Histograms expand to _bucket, _sum and _count
+// series and summaries to quantile, _sum and _count series. Meters are
+// written as counters of their events.
+//
+// Metrics that cannot be collected are left out; the others are still
+// written, and the collection error is returned.
+func WritePrometheus(w io.Writer, r *Registry) error {
+	samples, err := r.Collect()
+	return errors.Join(encodePrometheus(w, samples), err)
+}
+
+// encodePrometheus writes samples, sorted by name, to w in the Prometheus
+// text exposition format.
+func encodePrometheus(w io.Writer, samples []Sample) error {
+	bw := bufio.NewWriter(w)
+	enc := textEncoder{w: bw}
+
+	for i, s := range samples {
+		if i == 0 || s.Name != samples[i-1].Name {
+			enc.writeHeader(s)
+		}
+		enc.writeMetric(s)
+	}
+
+	return bw.Flush()
+}
+
+// textEncoder writes the Prometheus text format.
+type textEncoder struct {
+	w *bufio.Writer
+}
+
+// writeHeader writes the # HELP and # TYPE lines of the family of s.
+func (e *textEncoder) writeHeader(s Sample) {
+	if s.Help != "" {
+		fmt.Fprintf(e.w, "# HELP %s %s\n", s.Name, escapeHelp(s.Help))
+	}
+	fmt.Fprintf(e.w, "# TYPE %s %s\n", s.Name, prometheusType(s.Type))
+}
+
+func (e *textEncoder) writeMetric(s Sample) {
+	name, labels := s.Name, s.Labels
+
+	switch {
+	case s.Histogram != nil:
+		h := s.Histogram
+		for _, b := range h.Buckets {
+			e.writeSample(name+"_bucket", labels, "le", formatFloat(b.UpperBound), formatUint(b.Count))
+		}
+		e.writeSample(name+"_bucket", labels, "le", "+Inf", formatUint(h.Count))
+		e.writeSample(name+"_sum", labels, "", "", formatFloat(h.Sum))
+		e.writeSample(name+"_count", labels, "", "", formatUint(h.Count))
+	case s.Summary != nil:
+		sum := s.Summary
+		for _, q := range sum.Quantiles {
+			e.writeSample(name, labels, "quantile", formatFloat(q.Quantile), formatFloat(q.Value))
+		}
+		e.writeSample(name+"_sum", labels, "", "", formatFloat(sum.Sum))
+		e.writeSample(name+"_count", labels, "", "", formatUint(sum.Count))
+	case s.Meter != nil:
+		e.writeSample(name, labels, "", "", strconv.FormatInt(s.Meter.Count, 10))
+	default:
+		e.writeSample(name, labels, "", "", s.formatValue())
+	}
+}
+
+// writeSample writes a single sample line. If extraName is not empty, the
+// extra label is appended after the metric's own labels.
+func (e *textEncoder) writeSample(name string, labels Labels, extraName, extraValue, value string) {
+	e.w.WriteString(name)
+	writeLabels(e.w, labels, extraName, extraValue)
+	e.w.WriteByte(' ')
+	e.w.WriteString(value)
+	e.w.WriteByte('\n')
+}
+
+// writeLabels writes labels sorted by name in {name="value",...} notation,
+// followed by the optional extra label.
+func writeLabels(w *bufio.Writer, labels Labels, extraName, extraValue string) {
+	if len(labels) == 0 && extraName == "" {
+		return
+	}
+
+	w.WriteByte('{')
+	for i, name := range labels.names() {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(name)
+		w.WriteString(`="`)
+		w.WriteString(escapeLabelValue(labels[name]))
+		w.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(labels) > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(extraName)
+		w.WriteString(`="`)
+		w.WriteString(escapeLabelValue(extraValue))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+// prometheusType returns the # TYPE name for a metric type.
+func prometheusType(t MetricType) string {
+	switch t {
+	case TypeCounter, TypeGauge, TypeHistogram, TypeSummary:
+		return t.String()
+	case TypeMeter:
+		return TypeCounter.String()
+	default:
+		return "untyped"
+	}
+}
+
+// helpText returns the help text of a metric: the help of its Desc or,
+// failing that, the result of its Help method if it has one.
+func helpText(m Metric) string {
+	if help := descOf(m).Help; help != "" {
+		return help
+	}
+	if h, ok := m.(interface{ Help() string }); ok {
+		return h.Help()
+	}
+	return ""
+}
+
+// helpReplacer escapes help text as required by the Prometheus text format.
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+// formatFloat formats a float the way Prometheus expects, including the
+// special values +Inf, -Inf and NaN.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// helpfulGauge is a Gauge with help text, used to test # HELP output.
+type helpfulGauge struct {
+	*Gauge
+	help string
+}
+
+func (g helpfulGauge) Help() string {
+	return g.help
+}
+
+// unsupportedMetric is a Metric whose value cannot be encoded.
+type unsupportedMetric struct{}
+
+func (unsupportedMetric) Name() string       { return "unsupported" }
+func (unsupportedMetric) Type() MetricType   { return TypeGauge }
+func (unsupportedMetric) Value() interface{} { return "not a number" }
+
+// errWriter is an io.Writer that always fails.
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+// TestWritePrometheus tests the Prometheus text exposition encoder.
+func TestWritePrometheus(t *testing.T) {
+	t.Run("encodes all metric types in name order", func(t *testing.T) {
+		r := NewRegistry(0)
+
+		requests := NewCounterVec("http_requests_total", []string{"method", "path"})
+		get, _ := requests.WithLabelValues("GET", `/a"b`)
+		get.Add(3)
+		post, _ := requests.WithLabelValues("POST", "/")
+		post.Inc()
+
+		temperature := helpfulGauge{Gauge: NewGauge("temperature_celsius"), help: "Current\ntemperature."}
+		temperature.Set(21.5)
+
+		latency := NewHistogram("latency_seconds", []float64{0.1, 1})
+		latency.Observe(0.05)
+		latency.Observe(0.5)
+		latency.Observe(2)
+
+		rpc := NewSummary("rpc_seconds", SummaryConfig{Objectives: map[float64]float64{0.5: 0.05}})
+		rpc.Observe(1)
+
+		for _, m := range []Metric{requests, temperature, latency, rpc, NewCounterVec("empty_total", []string{"x"})} {
+			if err := r.Register(m); err != nil {
+				t.Fatalf("Register(%s) failed: %v", m.Name(), err)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := WritePrometheus(&buf, r); err != nil {
+			t.Fatalf("WritePrometheus() error = %v", err)
+		}
+
+		want := strings.Join([]string{
+			`# TYPE http_requests_total counter`,
+			`http_requests_total{method="GET",path="/a\"b"} 3`,
+			`http_requests_total{method="POST",path="/"} 1`,
+			`# TYPE latency_seconds histogram`,
+			`latency_seconds_bucket{le="0.1"} 1`,
+			`latency_seconds_bucket{le="1"} 2`,
+			`latency_seconds_bucket{le="+Inf"} 3`,
+			`latency_seconds_sum 2.55`,
+			`latency_seconds_count 3`,
+			`# TYPE rpc_seconds summary`,
+			`rpc_seconds{quantile="0.5"} 1`,
+			`rpc_seconds_sum 1`,
+			`rpc_seconds_count 1`,
+			`# HELP temperature_celsius Current\ntemperature.`,
+			`# TYPE temperature_celsius gauge`,
+			`temperature_celsius 21.5`,
+			``,
+		}, "\n")
+
+		if got := buf.String(); got != want {
+			t.Errorf("WritePrometheus() output:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("labels precede le label", func(t *testing.T) {
+		r := NewRegistry(0)
+		v := NewHistogramVec("latency_seconds", []string{"route"}, []float64{1})
+		h, _ := v.WithLabelValues("/users")
+		h.Observe(0.5)
+		_ = r.Register(v)
+
+		var buf bytes.Buffer
+		if err := WritePrometheus(&buf, r); err != nil {
+			t.Fatalf("WritePrometheus() error = %v", err)
+		}
+
+		if want := `latency_seconds_bucket{route="/users",le="1"} 1`; !strings.Contains(buf.String(), want) {
+			t.Errorf("WritePrometheus() output missing %q:\n%s", want, buf.String())
+		}
+	})
+
+	t.Run("encodes special float values", func(t *testing.T) {
+		tests := []struct {
+			value float64
+			want  string
+		}{
+			{value: math.Inf(1), want: "+Inf"},
+			{value: math.Inf(-1), want: "-Inf"},
+			{value: math.NaN(), want: "NaN"},
+			{value: 1e21, want: "1e+21"},
+		}
+
+		for _, tt := range tests {
+			if got := formatFloat(tt.value); got != tt.want {
+				t.Errorf("formatFloat(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("rejects unsupported values and writes the rest", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(unsupportedMetric{})
+		_ = r.Register(NewCounter("healthy_total"))
+
+		var buf bytes.Buffer
+		if err := WritePrometheus(&buf, r); err == nil {
+			t.Error("WritePrometheus() with unsupported value should return error")
+		}
+		if want := "healthy_total 0\n"; !strings.Contains(buf.String(), want) {
+			t.Errorf("WritePrometheus() = %q, want it to contain %q", buf.String(), want)
+		}
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(NewCounter("test"))
+
+		if err := WritePrometheus(errWriter{}, r); err == nil {
+			t.Error("WritePrometheus() to failing writer should return error")
+		}
+	})
+
+	t.Run("zero value registry writes nothing", func(t *testing.T) {
+		var r Registry
+		var buf bytes.Buffer
+
+		if err := WritePrometheus(&buf, &r); err != nil {
+			t.Fatalf("WritePrometheus() error = %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("WritePrometheus() wrote %q, want nothing", buf.String())
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// streamBufferSize is the number of raw observations a quantileStream
+// buffers before merging them into its compressed summary.
+const streamBufferSize = 500
+
+// objective is a targeted quantile with its allowed absolute error.
+type objective struct {
+	quantile float64
+	epsilon  float64
+}
+
+// ckmsSample is a single tuple of the CKMS summary.
+type ckmsSample struct {
+	value float64
+	width float64 // g in the paper: rank distance to the previous sample
+	delta float64 // Δ in the paper: rank uncertainty of this sample
+}
+
+// quantileStream estimates targeted quantiles over a stream of observations
+// using the biased quantiles algorithm of Cormode, Korn, Muthukrishnan and
+// Srivastava ("Effective Computation of Biased Quantiles over Data Streams").
+// Memory use is bounded by the objectives' error bounds, not by the number
+// of observations.
+//
+// A quantileStream is not safe for concurrent use.
+type quantileStream struct {
+	objectives []objective
+	n          float64
+	samples    []ckmsSample
+	buf        []float64
+	sorted     bool
+}
+
+func newQuantileStream(objectives []objective) *quantileStream {
+	return &quantileStream{
+		objectives: objectives,
+		buf:        make([]float64, 0, streamBufferSize),
+	}
+}
+
+// insert adds an observation to the stream.
+func (s *quantileStream) insert(v float64) {
+	s.buf = append(s.buf, v)
+	s.sorted = false
+	if len(s.buf) == cap(s.buf) {
+		s.flush()
+	}
+}
+
+// query returns the estimated value at quantile q, or NaN if the stream
+// is empty.
+func (s *quantileStream) query(q float64) float64 {
+	if len(s.samples) == 0 {
+		// Nothing merged yet; answer exactly from the raw buffer, which is
+		// both cheaper and more accurate for small data sets.
+		if len(s.buf) == 0 {
+			return math.NaN()
+		}
+		s.sortBuf()
+		i := int(math.Ceil(float64(len(s.buf))*q)) - 1
+		if i < 0 {
+			i = 0
+		}
+		return s.buf[i]
+	}
+
+	s.flush()
+
+	t := math.Ceil(q * s.n)
+	t += math.Ceil(s.invariant(t) / 2)
+	prev := s.samples[0]
+	var r float64
+	for _, c := range s.samples[1:] {
+		r += prev.width
+		if r+c.width+c.delta > t {
+			return prev.value
+		}
+		prev = c
+	}
+	return prev.value
+}
+
+// reset discards all observations.
+func (s *quantileStream) reset() {
+	s.n = 0
+	s.samples = s.samples[:0]
+	s.buf = s.buf[:0]
+}
+
+// invariant returns the maximum allowed rank error at rank r.
+func (s *quantileStream) invariant(r float64) float64 {
+	m := math.MaxFloat64
+	for _, o := range s.objectives {
+		var f float64
+		if o.quantile*s.n <= r {
+			f = (2 * o.epsilon * r) / o.quantile
+		} else {
+			f = (2 * o.epsilon * (s.n - r)) / (1 - o.quantile)
+		}
+		if f < m {
+			m = f
+		}
+	}
+	return m
+}
+
+func (s *quantileStream) sortBuf() {
+	if !s.sorted {
+		sort.Float64s(s.buf)
+		s.sorted = true
+	}
+}
+
+// flush merges the raw buffer into the compressed summary.
+func (s *quantileStream) flush() {
+	if len(s.buf) == 0 {
+		return
+	}
+	s.sortBuf()
+
+	var r float64
+	i := 0
+	for _, v := range s.buf {
+		inserted := false
+		for ; i < len(s.samples); i++ {
+			c := s.samples[i]
+			if c.value > v {
+				delta := math.Max(0, math.Floor(s.invariant(r))-1)
+				s.samples = append(s.samples, ckmsSample{})
+				copy(s.samples[i+1:], s.samples[i:])
+				s.samples[i] = ckmsSample{value: v, width: 1, delta: delta}
+				i++
+				inserted = true
+				break
+			}
+			r += c.width
+		}
+		if !inserted {
+			s.samples = append(s.samples, ckmsSample{value: v, width: 1})
+			i++
+		}
+		s.n++
+		r++
+	}
+
+	s.buf = s.buf[:0]
+	s.compress()
+}
+
+// compress merges adjacent samples whose combined uncertainty stays within
+// the invariant.
+func (s *quantileStream) compress() {
+	if len(s.samples) < 2 {
+		return
+	}
+
+	xi := len(s.samples) - 1
+	x := s.samples[xi]
+	r := s.n - 1 - x.width
+
+	for i := len(s.samples) - 2; i >= 0; i-- {
+		c := s.samples[i]
+		if c.width+x.width+x.delta <= s.invariant(r) {
+			x.width += c.width
+			s.samples[xi] = x
+			copy(s.samples[i:], s.samples[i+1:])
+			s.samples = s.samples[:len(s.samples)-1]
+			xi--
+		} else {
+			x = c
+			xi = i
+		}
+		r -= c.width
+	}
+}
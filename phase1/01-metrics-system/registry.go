@@ -1,54 +1,234 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
+
+	"go.uber.org/atomic"
 )
 
 // Registry is a thread-safe collection of metrics.
 // The zero value is ready to use.
+//
+// The registered metrics and collectors are held in an immutable state that
+// every change copies and replaces, so that lookups and collections never
+// wait: they read the current state through an atomic pointer. Changes are
+// serialized and cost O(n) in the number of registered metrics, which suits
+// registries that are populated at startup and read on every request.
 type Registry struct {
-	mu      sync.RWMutex
-	metrics map[string]Metric
+	mu     sync.Mutex // serializes changes to state
+	state  atomic.Pointer[registryState]
+	naming *NamingPolicy // nil selects the zero NamingPolicy
+
+	collectorTimeout time.Duration
+}
+
+// registryState is an immutable snapshot of the contents of a Registry.
+// It is never modified once published; changes are made to a clone.
+type registryState struct {
+	metrics    map[string]Metric
+	collectors map[string]*registeredCollector // by described name
+}
+
+// emptyRegistryState is the state of a zero-value Registry.
+var emptyRegistryState = &registryState{}
+
+// clone returns a copy of s that may be modified before it is published.
+func (s *registryState) clone() *registryState {
+	next := &registryState{
+		metrics:    make(map[string]Metric, len(s.metrics)+1),
+		collectors: make(map[string]*registeredCollector, len(s.collectors)),
+	}
+	for name, metric := range s.metrics {
+		next.metrics[name] = metric
+	}
+	for name, rc := range s.collectors {
+		next.collectors[name] = rc
+	}
+	return next
+}
+
+// RegistryOption configures a Registry created with NewRegistry.
+type RegistryOption interface {
+	applyRegistry(*Registry)
+}
+
+type namingPolicyOption NamingPolicy
+
+func (o namingPolicyOption) applyRegistry(r *Registry) {
+	p := NamingPolicy(o)
+	r.naming = &p
+}
+
+// WithNamingPolicy sets the naming rules the registry enforces on
+// Register. Without it, only empty names are rejected.
+func WithNamingPolicy(p NamingPolicy) RegistryOption {
+	return namingPolicyOption(p)
 }
 
 // NewRegistry creates a new metrics registry with the specified initial capacity.
 // If capacity is 0, a default capacity is used.
-func NewRegistry(capacity int) *Registry {
+func NewRegistry(capacity int, opts ...RegistryOption) *Registry {
 	if capacity <= 0 {
 		capacity = 16 // default capacity hint
 	}
-	return &Registry{
+	r := &Registry{}
+	r.state.Store(&registryState{
 		metrics: make(map[string]Metric, capacity),
+	})
+	for _, opt := range opts {
+		opt.applyRegistry(r)
 	}
+	return r
 }
 
 // Register adds a metric to the registry.
 // It returns ErrDuplicateMetric if a metric with the same name already exists.
-// It returns ErrInvalidMetricName if the metric name is empty.
+// It returns a *NameError wrapping ErrInvalidMetricName if the metric name
+// is empty or violates the registry's naming policy, and an error wrapping
+// ErrInvalidLabelName if one of its label names is invalid.
 func (r *Registry) Register(metric Metric) error {
-	if metric == nil {
-		return fmt.Errorf("cannot register nil metric")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.load()
+	if err := r.check(current, metric); err != nil {
+		return err
 	}
 
-	name := metric.Name()
-	if name == "" {
-		return ErrInvalidMetricName
+	next := current.clone()
+	next.metrics[metric.Name()] = metric
+	r.state.Store(next)
+	return nil
+}
+
+// MustRegister registers the given metrics, like Register, and panics if
+// any of them cannot be registered. Either all metrics are registered or
+// none is. It is intended for package initialization, where a failure is
+// a programming error:
+//
+//	var requests = metrics.NewCounter("requests_total")
+//
+//	func init() {
+//		registry.MustRegister(requests)
+//	}
+func (r *Registry) MustRegister(metrics ...Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.load()
+	next := current.clone()
+	for _, metric := range metrics {
+		if err := r.check(current, metric); err != nil {
+			panic(err)
+		}
+		name := metric.Name()
+		if _, exists := next.metrics[name]; exists {
+			panic(fmt.Errorf("%w: %s", ErrDuplicateMetric, name))
+		}
+		next.metrics[name] = metric
+	}
+	r.state.Store(next)
+}
+
+// GetOrRegisterCounter returns the counter registered under name, or
+// creates and registers a new one with the given options if there is none.
+// Options are ignored when an existing counter is returned.
+// It returns ErrMetricTypeMismatch if another kind of metric is registered
+// under name, and a *NameError if name is invalid.
+func (r *Registry) GetOrRegisterCounter(name string, opts ...Option) (*Counter, error) {
+	return getOrRegister(r, name, func() *Counter {
+		return NewCounter(name, opts...)
+	})
+}
+
+// GetOrRegisterGauge returns the gauge registered under name, or creates
+// and registers a new one with the given options if there is none.
+// Options are ignored when an existing gauge is returned.
+// It returns ErrMetricTypeMismatch if another kind of metric is registered
+// under name, and a *NameError if name is invalid.
+func (r *Registry) GetOrRegisterGauge(name string, opts ...Option) (*Gauge, error) {
+	return getOrRegister(r, name, func() *Gauge {
+		return NewGauge(name, opts...)
+	})
+}
+
+// getOrRegister returns the metric of type M registered under name, or
+// registers the result of newMetric. Registration happens under the write
+// lock after a second lookup, so concurrent callers share one instance.
+func getOrRegister[M Metric](r *Registry, name string, newMetric func() M) (M, error) {
+	if existing, exists := r.load().metrics[name]; exists {
+		return assertMetric[M](name, existing)
 	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Initialize map on first use (zero-value usability)
-	if r.metrics == nil {
-		r.metrics = make(map[string]Metric, 16)
+	current := r.load()
+	if existing, exists := current.metrics[name]; exists {
+		return assertMetric[M](name, existing)
 	}
 
-	if _, exists := r.metrics[name]; exists {
-		return fmt.Errorf("%w: %s", ErrDuplicateMetric, name)
+	var zero M
+	m := newMetric()
+	if err := r.check(current, m); err != nil {
+		return zero, err
+	}
+
+	next := current.clone()
+	next.metrics[name] = m
+	r.state.Store(next)
+	return m, nil
+}
+
+// assertMetric returns existing as an M, or ErrMetricTypeMismatch if it is
+// another kind of metric.
+func assertMetric[M Metric](name string, existing Metric) (M, error) {
+	m, ok := existing.(M)
+	if !ok {
+		var zero M
+		return zero, fmt.Errorf("%w: %s is registered as %T, not %T",
+			ErrMetricTypeMismatch, name, existing, zero)
+	}
+	return m, nil
+}
+
+// load returns the current state of the registry.
+func (r *Registry) load() *registryState {
+	if s := r.state.Load(); s != nil {
+		return s
+	}
+	return emptyRegistryState
+}
+
+// check returns the error Register would return for metric in state s, or
+// nil if it can be registered.
+func (r *Registry) check(s *registryState, metric Metric) error {
+	if metric == nil {
+		return fmt.Errorf("cannot register nil metric")
+	}
+	if err := r.checkName(s, metric.Name(), metric.Type()); err != nil {
+		return err
 	}
+	return checkLabelNames(descOf(metric))
+}
 
-	r.metrics[name] = metric
+// checkName returns an error if name is invalid for a metric of type t or
+// already taken by a metric or collector in state s.
+func (r *Registry) checkName(s *registryState, name string, t MetricType) error {
+	if err := r.namingPolicy().validate(name, t); err != nil {
+		return err
+	}
+
+	_, isMetric := s.metrics[name]
+	_, isCollected := s.collectors[name]
+	if isMetric || isCollected {
+		return fmt.Errorf("%w: %s", ErrDuplicateMetric, name)
+	}
 	return nil
 }
 
@@ -58,70 +238,196 @@ func (r *Registry) Unregister(name string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.metrics == nil {
+	current := r.load()
+	if _, exists := current.metrics[name]; !exists {
 		return fmt.Errorf("%w: %s", ErrMetricNotFound, name)
 	}
 
-	if _, exists := r.metrics[name]; !exists {
-		return fmt.Errorf("%w: %s", ErrMetricNotFound, name)
+	next := current.clone()
+	delete(next.metrics, name)
+	r.state.Store(next)
+	return nil
+}
+
+// namingPolicy returns the naming policy the registry enforces.
+func (r *Registry) namingPolicy() NamingPolicy {
+	if r.naming == nil {
+		return NamingPolicy{}
 	}
+	return *r.naming
+}
 
-	delete(r.metrics, name)
-	return nil
+// unregisterIf removes the metric registered under name only if it is m.
+// It reports whether m was removed.
+func (r *Registry) unregisterIf(name string, m Metric) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.load()
+	if registered, exists := current.metrics[name]; !exists || registered != m {
+		return false
+	}
+
+	next := current.clone()
+	delete(next.metrics, name)
+	r.state.Store(next)
+	return true
 }
 
 // Get retrieves a metric by name.
 // It returns the metric and true if found, or nil and false if not found.
+// It never waits, even while metrics are being registered.
 func (r *Registry) Get(name string) (Metric, bool) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	metric, exists := r.load().metrics[name]
+	return metric, exists
+}
+
+// Collect returns a typed sample for every registered metric, every child
+// of a registered vector and every sample of a registered collector,
+// sorted by name. Children of a vector are sorted by label values. Empty
+// vectors yield no samples.
+//
+// Metrics whose value is not a number, HistogramSnapshot, SummarySnapshot
+// or MeterSnapshot are left out and reported in the returned error, as are
+// collectors that fail, panic or time out; the samples of all other
+// metrics are still returned.
+func (r *Registry) Collect() ([]Sample, error) {
+	return r.CollectContext(context.Background())
+}
 
-	if r.metrics == nil {
-		return nil, false
+// CollectContext is like Collect, but stops waiting for collectors once
+// ctx is done.
+func (r *Registry) CollectContext(ctx context.Context) ([]Sample, error) {
+	now := time.Now()
+	state := r.load()
+	metrics := state.sortedMetrics()
+
+	samples := make([]Sample, 0, len(metrics))
+	var errs []error
+	for _, metric := range metrics {
+		children := []Metric{metric}
+		if family, ok := metric.(metricFamily); ok {
+			children = family.children()
+		}
+
+		help, unit := helpText(metric), descOf(metric).Unit
+		for _, child := range children {
+			s := Sample{
+				Name:      metric.Name(),
+				Type:      metric.Type(),
+				Labels:    labelsOf(child),
+				Help:      help,
+				Unit:      unit,
+				Created:   createdOf(child),
+				Timestamp: now,
+			}
+			if err := s.setValue(child); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			samples = append(samples, s)
+		}
 	}
 
-	metric, exists := r.metrics[name]
-	return metric, exists
+	collected, err := r.collectCollectors(ctx, state, now)
+	if len(collected) > 0 {
+		samples = append(samples, collected...)
+		sort.SliceStable(samples, func(i, j int) bool {
+			return samples[i].Name < samples[j].Name
+		})
+	}
+	errs = append(errs, err)
+
+	return samples, errors.Join(errs...)
+}
+
+// Descs returns the descriptors of all registered metrics and of the
+// metrics described by registered collectors, sorted by name.
+func (r *Registry) Descs() []Desc {
+	state := r.load()
+	metrics := state.sortedMetrics()
+
+	descs := make([]Desc, 0, len(metrics)+len(state.collectors))
+	for _, metric := range metrics {
+		descs = append(descs, descOf(metric))
+	}
+	for name, rc := range state.collectors {
+		descs = append(descs, rc.descs[name].clone())
+	}
+
+	sort.Slice(descs, func(i, j int) bool {
+		return descs[i].Name < descs[j].Name
+	})
+	return descs
 }
 
 // Snapshot returns a copy of all metrics and their current values.
 // This is a defensive copy to prevent external mutation of the internal state.
 // The returned map is safe to modify by the caller.
+//
+// Children of metric vectors are keyed by name and labels, e.g.
+// http_requests_total{method="GET"}. Registered metrics map to what their
+// Value method returns, so counters keep their full int64 precision and
+// custom value types are included as is. Samples of collectors map to
+// int64 for integral counters, float64 for other counters and gauges, and
+// HistogramSnapshot, SummarySnapshot or MeterSnapshot.
+//
+// Snapshot is kept for compatibility; new code should use Collect, which
+// preserves metric types and metadata.
 func (r *Registry) Snapshot() map[string]interface{} {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	state := r.load()
 
-	if r.metrics == nil {
-		return make(map[string]interface{})
+	snapshot := make(map[string]interface{}, len(state.metrics))
+	for _, metric := range state.metrics {
+		if family, ok := metric.(metricFamily); ok {
+			for _, child := range family.children() {
+				snapshot[metricKey(metric.Name(), labelsOf(child))] = child.Value()
+			}
+			continue
+		}
+		snapshot[metric.Name()] = metric.Value()
 	}
 
-	// Create a defensive copy with capacity hint
-	snapshot := make(map[string]interface{}, len(r.metrics))
-	for name, metric := range r.metrics {
-		snapshot[name] = metric.Value()
+	// Collectors that fail are left out of the snapshot.
+	collected, _ := r.collectCollectors(context.Background(), state, time.Now())
+	for _, s := range collected {
+		switch {
+		case s.Histogram != nil:
+			snapshot[s.key()] = *s.Histogram
+		case s.Summary != nil:
+			snapshot[s.key()] = *s.Summary
+		case s.Meter != nil:
+			snapshot[s.key()] = *s.Meter
+		default:
+			snapshot[s.key()] = s.scalar()
+		}
 	}
 
 	return snapshot
 }
 
-// Len returns the number of registered metrics.
-func (r *Registry) Len() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	if r.metrics == nil {
-		return 0
+// sortedMetrics returns the metrics of s sorted by name.
+func (s *registryState) sortedMetrics() []Metric {
+	metrics := make([]Metric, 0, len(s.metrics))
+	for _, metric := range s.metrics {
+		metrics = append(metrics, metric)
 	}
 
-	return len(r.metrics)
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name() < metrics[j].Name()
+	})
+	return metrics
 }
 
-// Clear removes all metrics from the registry.
+// Len returns the number of registered metrics.
+func (r *Registry) Len() int {
+	return len(r.load().metrics)
+}
+
+// Clear removes all metrics and collectors from the registry.
 func (r *Registry) Clear() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.metrics != nil {
-		r.metrics = make(map[string]Metric, 16)
-	}
+	r.state.Store(emptyRegistryState)
 }
@@ -0,0 +1,397 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestRegistry_GetOrRegister tests get-or-register accessors.
+func TestRegistry_GetOrRegister(t *testing.T) {
+	t.Run("creates and then returns the same counter", func(t *testing.T) {
+		var r Registry
+
+		c1, err := r.GetOrRegisterCounter("jobs_total", WithHelp("Jobs."))
+		if err != nil {
+			t.Fatalf("GetOrRegisterCounter() error = %v", err)
+		}
+		c2, err := r.GetOrRegisterCounter("jobs_total")
+		if err != nil {
+			t.Fatalf("second GetOrRegisterCounter() error = %v", err)
+		}
+		if c1 != c2 {
+			t.Error("GetOrRegisterCounter() returned a different counter for the same name")
+		}
+		if got := c2.Desc().Help; got != "Jobs." {
+			t.Errorf("Desc().Help = %q, want options of the first registration", got)
+		}
+	})
+
+	t.Run("returns existing gauge", func(t *testing.T) {
+		r := NewRegistry(0)
+		g := NewGauge("queue_depth")
+		_ = r.Register(g)
+
+		got, err := r.GetOrRegisterGauge("queue_depth")
+		if err != nil || got != g {
+			t.Errorf("GetOrRegisterGauge() = %p, %v, want %p", got, err, g)
+		}
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			existing Metric
+			get      func(*Registry) error
+		}{
+			{
+				name:     "gauge requested as counter",
+				existing: NewGauge("m"),
+				get: func(r *Registry) error {
+					_, err := r.GetOrRegisterCounter("m")
+					return err
+				},
+			},
+			{
+				name:     "counter requested as gauge",
+				existing: NewCounter("m"),
+				get: func(r *Registry) error {
+					_, err := r.GetOrRegisterGauge("m")
+					return err
+				},
+			},
+			{
+				name:     "counter vector requested as counter",
+				existing: NewCounterVec("m", []string{"x"}),
+				get: func(r *Registry) error {
+					_, err := r.GetOrRegisterCounter("m")
+					return err
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r := NewRegistry(0)
+				_ = r.Register(tt.existing)
+
+				if err := tt.get(r); !errors.Is(err, ErrMetricTypeMismatch) {
+					t.Errorf("error = %v, want ErrMetricTypeMismatch", err)
+				}
+				if got, _ := r.Get("m"); got != tt.existing {
+					t.Error("existing metric was replaced")
+				}
+			})
+		}
+	})
+
+	t.Run("rejects invalid names", func(t *testing.T) {
+		r := NewRegistry(0, WithNamingPolicy(PrometheusNamingPolicy()))
+
+		if _, err := r.GetOrRegisterGauge("queue depth"); !errors.Is(err, ErrInvalidMetricName) {
+			t.Errorf("GetOrRegisterGauge() error = %v, want ErrInvalidMetricName", err)
+		}
+		if r.Len() != 0 {
+			t.Errorf("Len() = %d, want 0", r.Len())
+		}
+	})
+
+	t.Run("concurrent callers share one instance", func(t *testing.T) {
+		r := NewRegistry(0)
+
+		const goroutines = 50
+		counters := make([]*Counter, goroutines)
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+		for i := 0; i < goroutines; i++ {
+			go func(i int) {
+				defer wg.Done()
+				c, err := r.GetOrRegisterCounter("shared_total")
+				if err != nil {
+					t.Errorf("GetOrRegisterCounter() error = %v", err)
+					return
+				}
+				c.Inc()
+				counters[i] = c
+			}(i)
+		}
+		wg.Wait()
+
+		for _, c := range counters {
+			if c != counters[0] {
+				t.Fatal("concurrent GetOrRegisterCounter() returned different counters")
+			}
+		}
+		if got := counters[0].Load(); got != goroutines {
+			t.Errorf("Load() = %d, want %d", got, goroutines)
+		}
+	})
+}
+
+// TestRegistry_MustRegister tests registration that panics on failure.
+func TestRegistry_MustRegister(t *testing.T) {
+	mustPanic := func(t *testing.T, f func()) error {
+		t.Helper()
+
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			f()
+		}()
+		if recovered == nil {
+			t.Fatal("MustRegister() did not panic")
+		}
+		err, ok := recovered.(error)
+		if !ok {
+			t.Fatalf("MustRegister() panicked with %T, want error", recovered)
+		}
+		return err
+	}
+
+	t.Run("registers all metrics", func(t *testing.T) {
+		r := NewRegistry(0)
+		r.MustRegister(NewCounter("a_total"), NewGauge("b"))
+
+		if r.Len() != 2 {
+			t.Errorf("Len() = %d, want 2", r.Len())
+		}
+	})
+
+	t.Run("panics on duplicate and registers nothing", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("b"))
+
+		err := mustPanic(t, func() { r.MustRegister(NewCounter("a_total"), NewGauge("b")) })
+		if !errors.Is(err, ErrDuplicateMetric) {
+			t.Errorf("panic = %v, want ErrDuplicateMetric", err)
+		}
+		if _, found := r.Get("a_total"); found {
+			t.Error("a_total was registered despite the panic")
+		}
+	})
+
+	t.Run("panics on duplicate within the batch", func(t *testing.T) {
+		r := NewRegistry(0)
+
+		err := mustPanic(t, func() { r.MustRegister(NewGauge("b"), NewGauge("b")) })
+		if !errors.Is(err, ErrDuplicateMetric) {
+			t.Errorf("panic = %v, want ErrDuplicateMetric", err)
+		}
+		if r.Len() != 0 {
+			t.Errorf("Len() = %d, want 0", r.Len())
+		}
+	})
+
+	t.Run("panics on invalid name", func(t *testing.T) {
+		var r Registry
+
+		err := mustPanic(t, func() { r.MustRegister(NewGauge("")) })
+		if !errors.Is(err, ErrInvalidMetricName) {
+			t.Errorf("panic = %v, want ErrInvalidMetricName", err)
+		}
+	})
+}
+
+// TestRegistry_CopyOnWrite tests that changes do not affect states already
+// being read.
+func TestRegistry_CopyOnWrite(t *testing.T) {
+	var r Registry
+	if _, found := r.Get("a"); found || r.Len() != 0 {
+		t.Fatal("zero-value Registry is not empty")
+	}
+
+	_ = r.Register(NewGauge("a"))
+	before := r.load()
+
+	_ = r.Register(NewGauge("b"))
+	_ = r.Unregister("a")
+	_ = r.RegisterCollector(NewGaugeFunc("c", func() float64 { return 1 }))
+
+	if len(before.metrics) != 1 || before.metrics["a"] == nil || len(before.collectors) != 0 {
+		t.Errorf("published state was modified: %v", before.metrics)
+	}
+	if _, found := r.Get("a"); found {
+		t.Error("Get(a) found an unregistered metric")
+	}
+	if _, found := r.Get("b"); !found {
+		t.Error("Get(b) did not find a registered metric")
+	}
+
+	r.Clear()
+	if r.Len() != 0 || len(r.Descs()) != 0 {
+		t.Errorf("Clear() left Len() = %d, %d descriptors", r.Len(), len(r.Descs()))
+	}
+	if err := r.Register(NewGauge("a")); err != nil {
+		t.Errorf("Register() after Clear() error = %v", err)
+	}
+}
+
+// TestRegistry_ConcurrentReadWrite tests lookups racing with changes.
+func TestRegistry_ConcurrentReadWrite(t *testing.T) {
+	const writers, readers, names = 4, 4, 50
+
+	var r Registry
+	_ = r.Register(NewGauge("stable"))
+
+	var wg sync.WaitGroup
+	wg.Add(writers + readers)
+	for w := 0; w < writers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < names; i++ {
+				name := fmt.Sprintf("gauge_%d_%d", w, i)
+				_ = r.Register(NewGauge(name))
+				if i%2 == 0 {
+					_ = r.Unregister(name)
+				}
+			}
+		}(w)
+	}
+	for i := 0; i < readers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < names; j++ {
+				if _, found := r.Get("stable"); !found {
+					t.Error("Get(stable) did not find a registered metric")
+				}
+				_, _ = r.Collect()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want := 1 + writers*names/2; r.Len() != want {
+		t.Errorf("Len() = %d, want %d", r.Len(), want)
+	}
+}
+
+// rwMutexRegistry is the read-locked map the Registry used before it was
+// made copy-on-write, kept as a baseline for benchmarks.
+type rwMutexRegistry struct {
+	mu      sync.RWMutex
+	metrics map[string]Metric
+}
+
+func (r *rwMutexRegistry) Register(metric Metric) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.metrics[metric.Name()]; exists {
+		return ErrDuplicateMetric
+	}
+	if r.metrics == nil {
+		r.metrics = make(map[string]Metric, 16)
+	}
+	r.metrics[metric.Name()] = metric
+	return nil
+}
+
+func (r *rwMutexRegistry) Unregister(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.metrics[name]; !exists {
+		return ErrMetricNotFound
+	}
+	delete(r.metrics, name)
+	return nil
+}
+
+func (r *rwMutexRegistry) Get(name string) (Metric, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metric, exists := r.metrics[name]
+	return metric, exists
+}
+
+// registryBenchmarks returns the registry implementations to compare.
+func registryBenchmarks() []struct {
+	name       string
+	register   func(Metric) error
+	unregister func(string) error
+	get        func(string) (Metric, bool)
+} {
+	cow, rw := &Registry{}, &rwMutexRegistry{}
+	return []struct {
+		name       string
+		register   func(Metric) error
+		unregister func(string) error
+		get        func(string) (Metric, bool)
+	}{
+		{"copy-on-write", cow.Register, cow.Unregister, cow.Get},
+		{"rwmutex", rw.Register, rw.Unregister, rw.Get},
+	}
+}
+
+// BenchmarkRegistry_Get benchmarks concurrent lookups, the read path that
+// copy-on-write makes wait-free.
+func BenchmarkRegistry_Get(b *testing.B) {
+	for _, bb := range registryBenchmarks() {
+		b.Run(bb.name, func(b *testing.B) {
+			for i := 0; i < 100; i++ {
+				_ = bb.register(NewCounter(fmt.Sprintf("counter_%d", i)))
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					bb.get("counter_42")
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkRegistry_RegisterUnregister benchmarks registering and
+// unregistering a metric in a registry of 100 metrics, the write path that
+// copy-on-write makes O(n).
+func BenchmarkRegistry_RegisterUnregister(b *testing.B) {
+	for _, bb := range registryBenchmarks() {
+		b.Run(bb.name, func(b *testing.B) {
+			for i := 0; i < 100; i++ {
+				_ = bb.register(NewCounter(fmt.Sprintf("counter_%d", i)))
+			}
+			counter := NewCounter("bench")
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = bb.register(counter)
+				_ = bb.unregister("bench")
+			}
+		})
+	}
+}
+
+// int32Metric is a custom Metric whose value type Collect does not know.
+type int32Metric struct{}
+
+func (int32Metric) Name() string       { return "custom" }
+func (int32Metric) Type() MetricType   { return TypeGauge }
+func (int32Metric) Value() interface{} { return int32(7) }
+
+// TestRegistry_Snapshot tests that Snapshot returns metric values as is.
+func TestRegistry_Snapshot(t *testing.T) {
+	r := NewRegistry(0)
+	big := NewCounter("big_total")
+	big.Add(1<<53 + 1) // not representable as a float64
+	_ = r.Register(big)
+	vec := NewCounterVec("requests_total", []string{"method"})
+	get, _ := vec.WithLabelValues("GET")
+	get.Add(3)
+	_ = r.Register(vec)
+	_ = r.Register(int32Metric{})
+	_ = r.RegisterCollector(NewGaugeFunc("queue_depth", func() float64 { return 1.5 }))
+
+	want := map[string]interface{}{
+		"big_total":                    int64(1<<53 + 1),
+		`requests_total{method="GET"}`: int64(3),
+		"custom":                       int32(7),
+		"queue_depth":                  1.5,
+	}
+	if got := r.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %v, want %v", got, want)
+	}
+}
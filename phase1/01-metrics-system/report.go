@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// DefaultReportInterval is the default interval between the reports of a
+// ReportLoop.
+const DefaultReportInterval = 10 * time.Second
+
+// Reporter sends samples to a push-based backend.
+//
+// Report must return once ctx is done. The samples are shared with the
+// other reporters of a ReportLoop and must not be modified. Reporters used
+// by a ReportLoop should be safe for concurrent use: a report abandoned
+// after its timeout may still be running when the loop is closed.
+type Reporter interface {
+	Report(ctx context.Context, samples []Sample) error
+}
+
+// ReportSink is a named Reporter of a ReportLoop.
+type ReportSink struct {
+	// Name identifies the sink in errors and in the errors metric. It must
+	// be unique within a loop.
+	Name string
+
+	// Reporter receives the samples.
+	Reporter Reporter
+
+	// Timeout bounds each report to this sink.
+	// Defaults to the interval of the loop.
+	Timeout time.Duration
+}
+
+// ReportLoopConfig configures a ReportLoop. Sinks is required; the other
+// fields default when zero.
+type ReportLoopConfig struct {
+	// Interval is the time between reports when running with Run.
+	// Defaults to DefaultReportInterval.
+	Interval time.Duration
+
+	// Sinks are the reporters the samples are sent to.
+	Sinks []ReportSink
+}
+
+// ReportLoop collects a Registry on an interval and sends the samples to
+// several reporters concurrently, so that push exporters share a single
+// collection and a single goroutine instead of owning one each.
+//
+// Every sink is reported to with its own timeout, so a slow backend does
+// not hold back the others, and a sink whose previous report is still
+// running is skipped. Failed reports, including timeouts and panics, are
+// counted by sink in the Errors metric.
+//
+// A ReportLoop is safe for concurrent use.
+type ReportLoop struct {
+	registry *Registry
+	cfg      ReportLoopConfig
+	sinks    []*reportSink
+	failures *CounterVec
+
+	flushMu sync.Mutex // serializes reports so sinks see samples in order
+
+	mu      sync.Mutex
+	closed  bool
+	stop    chan struct{} // closed by Close
+	running sync.WaitGroup
+}
+
+// reportSink is a sink of a ReportLoop with its state.
+type reportSink struct {
+	ReportSink
+	failures *Counter
+	running  atomic.Bool
+}
+
+// NewReportLoop creates a loop that reports the metrics of r to the sinks
+// of cfg. It returns an error if r is nil, if there are no sinks, or if a
+// sink has no reporter or a missing or duplicate name. Call Run to report
+// in the background, or Flush to report once.
+func NewReportLoop(r *Registry, cfg ReportLoopConfig) (*ReportLoop, error) {
+	if r == nil {
+		return nil, errors.New("report: registry is required")
+	}
+	if len(cfg.Sinks) == 0 {
+		return nil, errors.New("report: at least one sink is required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultReportInterval
+	}
+
+	l := &ReportLoop{
+		registry: r,
+		cfg:      cfg,
+		failures: NewCounterVec("report_errors_total", []string{"sink"},
+			WithHelp("Number of failed reports by sink.")),
+		stop: make(chan struct{}),
+	}
+
+	seen := make(map[string]struct{}, len(cfg.Sinks))
+	for _, sink := range cfg.Sinks {
+		switch _, dup := seen[sink.Name]; {
+		case sink.Name == "":
+			return nil, errors.New("report: sink name is required")
+		case dup:
+			return nil, fmt.Errorf("report: duplicate sink %q", sink.Name)
+		case sink.Reporter == nil:
+			return nil, fmt.Errorf("report: sink %s has no reporter", sink.Name)
+		}
+		seen[sink.Name] = struct{}{}
+
+		if sink.Timeout <= 0 {
+			sink.Timeout = cfg.Interval
+		}
+		// Created up front so that sinks without failures export 0. It
+		// cannot fail, the value matching the single label name.
+		failures, _ := l.failures.WithLabelValues(sink.Name)
+		l.sinks = append(l.sinks, &reportSink{ReportSink: sink, failures: failures})
+	}
+
+	return l, nil
+}
+
+// Errors returns the counter of failed reports, labeled by sink. It is not
+// registered anywhere; register it in a Registry to expose it.
+func (l *ReportLoop) Errors() *CounterVec {
+	return l.failures
+}
+
+// Run reports every interval until ctx is cancelled or the loop is closed.
+// Report errors are dropped; they are counted in Errors. Run returns
+// immediately if the loop is already closed.
+func (l *ReportLoop) Run(ctx context.Context) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.running.Add(1)
+	l.mu.Unlock()
+	defer l.running.Done()
+
+	ticker := time.NewTicker(l.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			_ = l.report(ctx)
+		}
+	}
+}
+
+// Flush collects the registry once and reports the samples to every sink,
+// waiting for all of them. Samples are reported even if some metrics could
+// not be collected. The returned error joins the collection error with
+// the errors of the sinks.
+func (l *ReportLoop) Flush(ctx context.Context) error {
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		return errors.New("report: loop is closed")
+	}
+	return l.report(ctx)
+}
+
+// Close stops Run and waits for it to return, and reports a final time so
+// that values recorded since the last report are not lost. The reporters
+// are not closed; they belong to the caller, who closes them after the
+// loop. Close is idempotent.
+func (l *ReportLoop) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.stop)
+	l.mu.Unlock()
+
+	l.running.Wait()
+
+	return l.report(context.Background())
+}
+
+// report collects the registry and fans the samples out to the sinks.
+func (l *ReportLoop) report(ctx context.Context) error {
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+
+	samples, err := l.registry.CollectContext(ctx)
+	if err != nil {
+		err = fmt.Errorf("report: collect: %w", err)
+	}
+
+	errs := make([]error, len(l.sinks))
+	var wg sync.WaitGroup
+	for i, sink := range l.sinks {
+		wg.Add(1)
+		go func(i int, sink *reportSink) {
+			defer wg.Done()
+			if errs[i] = sink.report(ctx, samples); errs[i] != nil {
+				sink.failures.Inc()
+			}
+		}(i, sink)
+	}
+	wg.Wait()
+
+	return errors.Join(append([]error{err}, errs...)...)
+}
+
+// report sends samples to the sink with its timeout, recovering from
+// panics. A report that outlives its timeout is abandoned, and the sink is
+// skipped until it returns.
+func (s *reportSink) report(ctx context.Context, samples []Sample) error {
+	if !s.running.CompareAndSwap(false, true) {
+		return fmt.Errorf("sink %s: previous report is still running", s.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer s.running.Store(false)
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("%w: %v", ErrReporterPanic, p)
+			}
+		}()
+
+		done <- s.Reporter.Report(ctx, samples)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("sink %s: %w", s.Name, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sink %s: %w", s.Name, ctx.Err())
+	}
+}
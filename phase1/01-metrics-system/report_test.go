@@ -0,0 +1,330 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// stubReporter is a Reporter with configurable behavior that records the
+// names of the samples it receives.
+type stubReporter struct {
+	report func(ctx context.Context) error
+	calls  atomic.Int64
+	closed atomic.Int64
+
+	mu    sync.Mutex
+	names [][]string
+}
+
+func (r *stubReporter) Report(ctx context.Context, samples []Sample) error {
+	r.calls.Inc()
+
+	names := make([]string, 0, len(samples))
+	for _, s := range samples {
+		names = append(names, s.Name)
+	}
+	r.mu.Lock()
+	r.names = append(r.names, names)
+	r.mu.Unlock()
+
+	if r.report == nil {
+		return nil
+	}
+	return r.report(ctx)
+}
+
+func (r *stubReporter) Close() error {
+	r.closed.Inc()
+	return nil
+}
+
+func (r *stubReporter) reports() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([][]string(nil), r.names...)
+}
+
+// reportErrors returns the failure count of a sink of l.
+func reportErrors(t *testing.T, l *ReportLoop, sink string) int64 {
+	t.Helper()
+
+	c, err := l.Errors().WithLabelValues(sink)
+	if err != nil {
+		t.Fatalf("WithLabelValues(%q) error = %v", sink, err)
+	}
+	return c.Load()
+}
+
+func TestNewReportLoop(t *testing.T) {
+	reporter := &stubReporter{}
+
+	tests := []struct {
+		name    string
+		sinks   []ReportSink
+		wantErr bool
+	}{
+		{name: "valid", sinks: []ReportSink{{Name: "a", Reporter: reporter}, {Name: "b", Reporter: reporter}}},
+		{name: "no sinks", wantErr: true},
+		{name: "missing name", sinks: []ReportSink{{Reporter: reporter}}, wantErr: true},
+		{name: "missing reporter", sinks: []ReportSink{{Name: "a"}}, wantErr: true},
+		{name: "duplicate name", sinks: []ReportSink{{Name: "a", Reporter: reporter}, {Name: "a", Reporter: reporter}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReportLoop(NewRegistry(0), ReportLoopConfig{Sinks: tt.sinks})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewReportLoop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("missing registry", func(t *testing.T) {
+		if _, err := NewReportLoop(nil, ReportLoopConfig{Sinks: []ReportSink{{Name: "a", Reporter: reporter}}}); err == nil {
+			t.Error("NewReportLoop(nil) error = nil, want error")
+		}
+	})
+}
+
+func TestReportLoop_Flush(t *testing.T) {
+	t.Run("fans out one collection to every sink", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(NewCounter("requests_total"))
+		_ = r.Register(NewGauge("temperature"))
+
+		a, b := &stubReporter{}, &stubReporter{}
+		l, _ := NewReportLoop(r, ReportLoopConfig{Sinks: []ReportSink{
+			{Name: "a", Reporter: a},
+			{Name: "b", Reporter: b},
+		}})
+
+		if err := l.Flush(context.Background()); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+		want := [][]string{{"requests_total", "temperature"}}
+		for name, sink := range map[string]*stubReporter{"a": a, "b": b} {
+			if got := sink.reports(); !reflect.DeepEqual(got, want) {
+				t.Errorf("sink %s reports = %v, want %v", name, got, want)
+			}
+		}
+	})
+
+	t.Run("failures are returned and counted by sink", func(t *testing.T) {
+		errBackend := errors.New("backend down")
+		healthy := &stubReporter{}
+		broken := &stubReporter{report: func(context.Context) error { return errBackend }}
+		l, _ := NewReportLoop(NewRegistry(0), ReportLoopConfig{Sinks: []ReportSink{
+			{Name: "healthy", Reporter: healthy},
+			{Name: "broken", Reporter: broken},
+		}})
+
+		for range 2 {
+			if err := l.Flush(context.Background()); !errors.Is(err, errBackend) {
+				t.Errorf("Flush() error = %v, want %v", err, errBackend)
+			}
+		}
+		if got := healthy.calls.Load(); got != 2 {
+			t.Errorf("healthy sink called %d times, want 2", got)
+		}
+		if got := reportErrors(t, l, "broken"); got != 2 {
+			t.Errorf("broken sink errors = %d, want 2", got)
+		}
+		if got := reportErrors(t, l, "healthy"); got != 0 {
+			t.Errorf("healthy sink errors = %d, want 0", got)
+		}
+	})
+
+	t.Run("panic is recovered", func(t *testing.T) {
+		l, _ := NewReportLoop(NewRegistry(0), ReportLoopConfig{Sinks: []ReportSink{
+			{Name: "broken", Reporter: &stubReporter{report: func(context.Context) error { panic("boom") }}},
+		}})
+
+		if err := l.Flush(context.Background()); !errors.Is(err, ErrReporterPanic) {
+			t.Errorf("Flush() error = %v, want ErrReporterPanic", err)
+		}
+		if got := reportErrors(t, l, "broken"); got != 1 {
+			t.Errorf("broken sink errors = %d, want 1", got)
+		}
+	})
+
+	t.Run("sink timeout passes to the reporter", func(t *testing.T) {
+		slow := &stubReporter{report: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		fast := &stubReporter{}
+		l, _ := NewReportLoop(NewRegistry(0), ReportLoopConfig{Sinks: []ReportSink{
+			{Name: "slow", Reporter: slow, Timeout: 10 * time.Millisecond},
+			{Name: "fast", Reporter: fast, Timeout: time.Hour},
+		}})
+
+		if err := l.Flush(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Flush() error = %v, want context.DeadlineExceeded", err)
+		}
+		if got := fast.calls.Load(); got != 1 {
+			t.Errorf("fast sink called %d times, want 1", got)
+		}
+	})
+
+	t.Run("hung sink is abandoned and not called again", func(t *testing.T) {
+		release := make(chan struct{})
+		hung := &stubReporter{report: func(context.Context) error {
+			<-release
+			return nil
+		}}
+		l, _ := NewReportLoop(NewRegistry(0), ReportLoopConfig{Sinks: []ReportSink{
+			{Name: "hung", Reporter: hung, Timeout: 10 * time.Millisecond},
+		}})
+
+		if err := l.Flush(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Flush() error = %v, want context.DeadlineExceeded", err)
+		}
+		if err := l.Flush(context.Background()); err == nil {
+			t.Error("Flush() while report is still running should return error")
+		}
+		if got := hung.calls.Load(); got != 1 {
+			t.Errorf("sink called %d times while hung, want 1", got)
+		}
+		if got := reportErrors(t, l, "hung"); got != 2 {
+			t.Errorf("hung sink errors = %d, want 2", got)
+		}
+
+		close(release)
+		deadline := time.Now().Add(5 * time.Second)
+		for l.Flush(context.Background()) != nil {
+			if time.Now().After(deadline) {
+				t.Fatal("Flush() still failing after the sink was released")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	t.Run("reports samples despite collection errors", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(NewGauge("healthy"))
+		_ = r.RegisterCollector(NewGaugeFunc("broken", func() float64 { panic("boom") }))
+
+		sink := &stubReporter{}
+		l, _ := NewReportLoop(r, ReportLoopConfig{Sinks: []ReportSink{{Name: "sink", Reporter: sink}}})
+
+		if err := l.Flush(context.Background()); !errors.Is(err, ErrCollectorPanic) {
+			t.Errorf("Flush() error = %v, want ErrCollectorPanic", err)
+		}
+		if got, want := sink.reports(), [][]string{{"healthy"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("sink reports = %v, want %v", got, want)
+		}
+		if got := reportErrors(t, l, "sink"); got != 0 {
+			t.Errorf("sink errors = %d, want 0", got)
+		}
+	})
+}
+
+func TestReportLoop_Run(t *testing.T) {
+	sink := &stubReporter{}
+	l, _ := NewReportLoop(NewRegistry(0), ReportLoopConfig{
+		Interval: time.Millisecond,
+		Sinks:    []ReportSink{{Name: "sink", Reporter: sink}},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for sink.calls.Load() < 2 {
+		select {
+		case <-deadline:
+			t.Fatal("background loop did not report")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after context cancellation")
+	}
+}
+
+func TestReportLoop_Close(t *testing.T) {
+	r := NewRegistry(0)
+	_ = r.Register(NewCounter("requests_total"))
+
+	sink := &stubReporter{}
+	l, _ := NewReportLoop(r, ReportLoopConfig{
+		Interval: time.Hour,
+		Sinks:    []ReportSink{{Name: "sink", Reporter: sink}},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		l.Run(context.Background())
+		close(done)
+	}()
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after Close")
+	}
+
+	if got, want := sink.reports(), [][]string{{"requests_total"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reports after Close = %v, want final flush %v", got, want)
+	}
+	if got := sink.closed.Load(); got != 0 {
+		t.Errorf("sink closed %d times, want 0: the loop does not own it", got)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if got := len(sink.reports()); got != 1 {
+		t.Errorf("reports after second Close = %d, want 1", got)
+	}
+	if err := l.Flush(context.Background()); err == nil {
+		t.Error("Flush() after Close should return error")
+	}
+}
+
+func TestStatsDReporter_ReportLoop(t *testing.T) {
+	l := newUDPListener(t)
+	r := NewRegistry(0)
+	c := NewCounter("requests")
+	_ = r.Register(c)
+
+	s, err := NewStatsDReporter(r, StatsDConfig{Address: l.addr()})
+	if err != nil {
+		t.Fatalf("NewStatsDReporter() error = %v", err)
+	}
+	loop, _ := NewReportLoop(r, ReportLoopConfig{Sinks: []ReportSink{{Name: "statsd", Reporter: s}}})
+
+	c.Add(3)
+	if err := loop.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got, want := l.lines(), []string{"requests:3|c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first report = %v, want %v", got, want)
+	}
+
+	c.Add(2)
+	if err := loop.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := l.lines(), []string{"requests:2|c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("final report = %v, want %v", got, want)
+	}
+}
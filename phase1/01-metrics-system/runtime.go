@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// runtimeMetricPrefix is prepended to the names of Go runtime metrics.
+const runtimeMetricPrefix = "go"
+
+var (
+	// runtimeSecondsBuckets are the histogram buckets for runtime
+	// durations, from 1µs to about 4s.
+	runtimeSecondsBuckets = ExponentialBuckets(1e-6, 4, 12)
+
+	// runtimeBytesBuckets are the histogram buckets for runtime sizes,
+	// from 8B to 32MiB.
+	runtimeBytesBuckets = ExponentialBuckets(8, 4, 12)
+)
+
+// RuntimeCollector is a Collector that exports the metrics of the Go
+// runtime, as reported by the runtime/metrics package: goroutine and
+// thread counts, heap and other memory classes, GC cycles and pauses,
+// scheduler latencies, CPU time classes and so on.
+//
+// Each runtime metric is exported under a name derived from its
+// runtime/metrics name, which the Go project keeps stable: the leading
+// slash is replaced by "go_", other punctuation by underscores, and
+// cumulative metrics are suffixed with _total. For example,
+// /sched/goroutines:goroutines becomes go_sched_goroutines_goroutines and
+// /gc/heap/allocs:bytes becomes go_gc_heap_allocs_bytes_total.
+//
+// Cumulative values are exported as counters and others as gauges.
+// Runtime histograms are exported as histograms with coarse, fixed
+// buckets (1µs to 4s for durations, 8B to 32MiB for sizes) to keep
+// scrapes small; their sums are estimated from the bucket midpoints,
+// since the runtime does not report them.
+//
+// Reading runtime metrics does not stop the world, so a RuntimeCollector
+// is cheap enough to collect on every scrape. It is safe for concurrent
+// use.
+type RuntimeCollector struct {
+	descs []Desc
+
+	mu      sync.Mutex
+	samples []metrics.Sample // one per descriptor, reused across reads
+}
+
+// Compile-time verification that RuntimeCollector implements Collector.
+var _ Collector = (*RuntimeCollector)(nil)
+
+// NewRuntimeCollector creates a collector of all metrics supported by the
+// running Go runtime.
+func NewRuntimeCollector() *RuntimeCollector {
+	c := &RuntimeCollector{}
+	seen := make(map[string]struct{})
+
+	for _, d := range metrics.All() {
+		var t MetricType
+		switch d.Kind {
+		case metrics.KindUint64, metrics.KindFloat64:
+			t = TypeGauge
+			if d.Cumulative {
+				t = TypeCounter
+			}
+		case metrics.KindFloat64Histogram:
+			t = TypeHistogram
+		default:
+			continue
+		}
+
+		name, unit := runtimeMetricName(d.Name, t)
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		c.descs = append(c.descs, Desc{
+			Name: name,
+			Type: t,
+			Help: d.Description,
+			Unit: unit,
+		})
+		c.samples = append(c.samples, metrics.Sample{Name: d.Name})
+	}
+
+	return c
+}
+
+// RegisterRuntimeCollector registers a RuntimeCollector in r.
+func RegisterRuntimeCollector(r *Registry) error {
+	return r.RegisterCollector(NewRuntimeCollector())
+}
+
+// Describe returns the descriptors of all exported runtime metrics.
+func (c *RuntimeCollector) Describe() []Desc {
+	descs := make([]Desc, len(c.descs))
+	for i, d := range c.descs {
+		descs[i] = d.clone()
+	}
+	return descs
+}
+
+// Collect reads the current runtime metrics.
+func (c *RuntimeCollector) Collect(context.Context) ([]Sample, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metrics.Read(c.samples)
+
+	out := make([]Sample, 0, len(c.samples))
+	for i, rs := range c.samples {
+		desc := c.descs[i]
+		s := Sample{Name: desc.Name}
+
+		switch rs.Value.Kind() {
+		case metrics.KindUint64:
+			s.Value = float64(rs.Value.Uint64())
+		case metrics.KindFloat64:
+			s.Value = rs.Value.Float64()
+		case metrics.KindFloat64Histogram:
+			s.Histogram = runtimeHistogram(rs.Value.Float64Histogram(), desc.Unit)
+		default:
+			// The metric is no longer supported by the runtime.
+			continue
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// runtimeMetricName converts a runtime/metrics name such as
+// /gc/heap/allocs:bytes into a metric name and unit.
+func runtimeMetricName(name string, t MetricType) (string, string) {
+	path, unit, _ := strings.Cut(strings.TrimPrefix(name, "/"), ":")
+	unit = sanitizeRuntimeName(unit)
+
+	full := runtimeMetricPrefix + "_" + sanitizeRuntimeName(path)
+	if unit != "" {
+		full += "_" + unit
+	}
+	if t == TypeCounter {
+		full += "_total"
+	}
+	return full, unit
+}
+
+// sanitizeRuntimeName replaces every character that may not appear in a
+// metric name with an underscore.
+func sanitizeRuntimeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// runtimeHistogram converts a runtime histogram into a HistogramSnapshot
+// with the fixed buckets for the unit, or the runtime's own finite bucket
+// boundaries for other units.
+func runtimeHistogram(h *metrics.Float64Histogram, unit string) *HistogramSnapshot {
+	var bounds []float64
+	switch unit {
+	case "seconds":
+		bounds = runtimeSecondsBuckets
+	case "bytes":
+		bounds = runtimeBytesBuckets
+	default:
+		for _, b := range h.Buckets[1:] {
+			if !math.IsInf(b, 0) {
+				bounds = append(bounds, b)
+			}
+		}
+	}
+
+	snapshot := &HistogramSnapshot{Buckets: make([]Bucket, len(bounds))}
+	for i, b := range bounds {
+		snapshot.Buckets[i].UpperBound = b
+	}
+
+	// Counts[i] covers [Buckets[i], Buckets[i+1]). Each runtime bucket is
+	// attributed to the first bound at or above its upper end, so that no
+	// observation is counted below its true value.
+	for i, n := range h.Counts {
+		if n == 0 {
+			continue
+		}
+		lo, hi := h.Buckets[i], h.Buckets[i+1]
+
+		if j := sort.SearchFloat64s(bounds, hi); j < len(bounds) {
+			snapshot.Buckets[j].Count += n
+		}
+		snapshot.Count += n
+
+		switch {
+		case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+			// Nothing is known about the values.
+		case math.IsInf(lo, -1):
+			snapshot.Sum += float64(n) * hi
+		case math.IsInf(hi, 1):
+			snapshot.Sum += float64(n) * lo
+		default:
+			snapshot.Sum += float64(n) * (lo + hi) / 2
+		}
+	}
+
+	// Make the bucket counts cumulative.
+	for i := 1; i < len(snapshot.Buckets); i++ {
+		snapshot.Buckets[i].Count += snapshot.Buckets[i-1].Count
+	}
+
+	return snapshot
+}
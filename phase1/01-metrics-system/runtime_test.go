@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"runtime/metrics"
+	"strings"
+	"testing"
+)
+
+// TestRuntimeMetricName tests the mapping of runtime/metrics names.
+func TestRuntimeMetricName(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      MetricType
+		wantName string
+		wantUnit string
+	}{
+		{
+			name:     "/sched/goroutines:goroutines",
+			typ:      TypeGauge,
+			wantName: "go_sched_goroutines_goroutines",
+			wantUnit: "goroutines",
+		},
+		{
+			name:     "/gc/heap/allocs:bytes",
+			typ:      TypeCounter,
+			wantName: "go_gc_heap_allocs_bytes_total",
+			wantUnit: "bytes",
+		},
+		{
+			name:     "/gc/heap/allocs-by-size:bytes",
+			typ:      TypeHistogram,
+			wantName: "go_gc_heap_allocs_by_size_bytes",
+			wantUnit: "bytes",
+		},
+		{
+			name:     "/cpu/classes/gc/mark/assist:cpu-seconds",
+			typ:      TypeCounter,
+			wantName: "go_cpu_classes_gc_mark_assist_cpu_seconds_total",
+			wantUnit: "cpu_seconds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotName, gotUnit := runtimeMetricName(tt.name, tt.typ)
+			if gotName != tt.wantName || gotUnit != tt.wantUnit {
+				t.Errorf("runtimeMetricName() = %q, %q, want %q, %q",
+					gotName, gotUnit, tt.wantName, tt.wantUnit)
+			}
+		})
+	}
+}
+
+// TestRuntimeHistogram tests rebucketing of runtime histograms.
+func TestRuntimeHistogram(t *testing.T) {
+	h := &metrics.Float64Histogram{
+		Counts:  []uint64{1, 2, 3, 4, 5},
+		Buckets: []float64{math.Inf(-1), 1, 2, 3, 10, math.Inf(1)},
+	}
+
+	t.Run("own bounds", func(t *testing.T) {
+		got := runtimeHistogram(h, "events")
+		want := &HistogramSnapshot{
+			Count: 15,
+			Sum:   1*1 + 2*1.5 + 3*2.5 + 4*6.5 + 5*10,
+			Buckets: []Bucket{
+				{UpperBound: 1, Count: 1},
+				{UpperBound: 2, Count: 3},
+				{UpperBound: 3, Count: 6},
+				{UpperBound: 10, Count: 10},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("runtimeHistogram() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("fixed bounds", func(t *testing.T) {
+		got := runtimeHistogram(h, "bytes")
+		if len(got.Buckets) != len(runtimeBytesBuckets) {
+			t.Fatalf("got %d buckets, want %d", len(got.Buckets), len(runtimeBytesBuckets))
+		}
+		if got.Count != 15 {
+			t.Errorf("Count = %d, want 15", got.Count)
+		}
+		// Values up to 3 fall in the 8 bucket, up to 10 in the 32 bucket
+		// and the overflow in none.
+		if b := got.Buckets[0]; b.UpperBound != 8 || b.Count != 6 {
+			t.Errorf("bucket %v = %d, want 8 = 6", b.UpperBound, b.Count)
+		}
+		if b := got.Buckets[1]; b.UpperBound != 32 || b.Count != 10 {
+			t.Errorf("bucket %v = %d, want 32 = 10", b.UpperBound, b.Count)
+		}
+		if last := got.Buckets[len(got.Buckets)-1]; last.Count != 10 {
+			t.Errorf("bucket %v = %d, want 10", last.UpperBound, last.Count)
+		}
+	})
+}
+
+// TestRuntimeCollector tests collecting runtime metrics through a registry.
+func TestRuntimeCollector(t *testing.T) {
+	r := NewRegistry(0)
+	if err := RegisterRuntimeCollector(r); err != nil {
+		t.Fatalf("RegisterRuntimeCollector() error = %v", err)
+	}
+	if err := RegisterRuntimeCollector(r); err == nil {
+		t.Error("second RegisterRuntimeCollector() succeeded, want error")
+	}
+
+	samples, err := r.Collect()
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	byName := make(map[string]Sample, len(samples))
+	for _, s := range samples {
+		byName[s.Name] = s
+	}
+
+	goroutines, ok := byName["go_sched_goroutines_goroutines"]
+	if !ok {
+		t.Fatal("go_sched_goroutines_goroutines not collected")
+	}
+	if goroutines.Type != TypeGauge || goroutines.Value < 1 {
+		t.Errorf("goroutines = %s %v, want gauge >= 1", goroutines.Type, goroutines.Value)
+	}
+	if goroutines.Help == "" {
+		t.Error("goroutines has no help text")
+	}
+
+	allocs, ok := byName["go_gc_heap_allocs_bytes_total"]
+	if !ok {
+		t.Fatal("go_gc_heap_allocs_bytes_total not collected")
+	}
+	if allocs.Type != TypeCounter || allocs.Unit != "bytes" || allocs.Value <= 0 {
+		t.Errorf("allocs = %s %q %v, want positive counter in bytes",
+			allocs.Type, allocs.Unit, allocs.Value)
+	}
+
+	latencies, ok := byName["go_sched_latencies_seconds"]
+	if !ok {
+		t.Fatal("go_sched_latencies_seconds not collected")
+	}
+	if latencies.Type != TypeHistogram || latencies.Histogram == nil {
+		t.Fatalf("latencies = %s, want histogram", latencies.Type)
+	}
+	if len(latencies.Histogram.Buckets) != len(runtimeSecondsBuckets) {
+		t.Errorf("latencies has %d buckets, want %d",
+			len(latencies.Histogram.Buckets), len(runtimeSecondsBuckets))
+	}
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, r); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "# TYPE go_sched_goroutines_goroutines gauge\n") {
+		t.Error("Prometheus output lacks the goroutines gauge")
+	}
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Sample is a typed point-in-time reading of a single metric or vector
+// child, as returned by Registry.Collect.
+//
+// Counters and gauges carry their reading in Value. Histograms carry it in
+// Histogram, summaries in Summary and meters in Meter; their Value is zero.
+type Sample struct {
+	// Name is the metric name. Children of a vector share the vector's name.
+	Name string
+
+	// Type is the metric type.
+	Type MetricType
+
+	// Labels are the label values of a vector child, or nil for a plain
+	// metric. The map is owned by the sample.
+	Labels Labels
+
+	// Help is the metric's help text, if it provides one.
+	Help string
+
+	// Unit is the metric's unit, if it declares one.
+	Unit string
+
+	// Value is the reading of a counter or gauge.
+	Value float64
+
+	// Histogram is the reading of a histogram, or nil.
+	Histogram *HistogramSnapshot
+
+	// Summary is the reading of a summary, or nil.
+	Summary *SummarySnapshot
+
+	// Meter is the reading of a meter, or nil.
+	Meter *MeterSnapshot
+
+	// Created is when the metric was created, or the zero time if the
+	// metric does not track it.
+	Created time.Time
+
+	// Exemplar is the most recent exemplar of a counter, or nil. Histogram
+	// exemplars are attached to the buckets of Histogram.
+	Exemplar *Exemplar
+
+	// Timestamp is when the sample was collected.
+	Timestamp time.Time
+}
+
+// setValue reads the value of m into s.
+func (s *Sample) setValue(m Metric) error {
+	switch v := m.Value().(type) {
+	case int64:
+		s.Value = float64(v)
+	case uint64:
+		s.Value = float64(v)
+	case int:
+		s.Value = float64(v)
+	case float64:
+		s.Value = v
+	case HistogramSnapshot:
+		s.Histogram = &v
+	case SummarySnapshot:
+		s.Summary = &v
+	case MeterSnapshot:
+		s.Meter = &v
+	default:
+		return fmt.Errorf("cannot collect metric %s: unsupported value type %T", s.Name, v)
+	}
+
+	if c, ok := m.(interface{ Exemplar() (Exemplar, bool) }); ok {
+		if ex, ok := c.Exemplar(); ok {
+			s.Exemplar = &ex
+		}
+	}
+	return nil
+}
+
+// key returns the sample's name and labels in the form used by
+// Registry.Snapshot, e.g. http_requests_total{method="GET"}.
+func (s Sample) key() string {
+	return metricKey(s.Name, s.Labels)
+}
+
+// scalar returns the value of a counter or gauge sample in the form
+// Registry.Snapshot has always used: int64 for integral counters and
+// float64 otherwise.
+func (s Sample) scalar() interface{} {
+	if n, ok := s.integer(); ok {
+		return n
+	}
+	return s.Value
+}
+
+// integer returns the value of a counter sample as an int64 if it is
+// integral. Counters are exposed as integers so that large counts are not
+// written in exponent notation.
+func (s Sample) integer() (int64, bool) {
+	if s.Type != TypeCounter || s.Value != math.Trunc(s.Value) ||
+		math.Abs(s.Value) >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(s.Value), true
+}
+
+// formatValue formats the value of a counter or gauge sample for the
+// Prometheus text format.
+func (s Sample) formatValue() string {
+	if n, ok := s.integer(); ok {
+		return strconv.FormatInt(n, 10)
+	}
+	return formatFloat(s.Value)
+}
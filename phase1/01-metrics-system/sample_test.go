@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRegistry_Collect tests typed sample collection.
+func TestRegistry_Collect(t *testing.T) {
+	t.Run("returns typed samples sorted by name and labels", func(t *testing.T) {
+		r := NewRegistry(0)
+
+		v := NewCounterVec("requests_total", []string{"method"})
+		post, _ := v.WithLabelValues("POST")
+		post.Add(2)
+		get, _ := v.WithLabelValues("GET")
+		get.Inc()
+		_ = r.Register(v)
+
+		g := &helpfulGauge{Gauge: NewGauge("temperature"), help: "Current temperature."}
+		g.Set(21.5)
+		_ = r.Register(g)
+
+		h := NewHistogram("latency", []float64{1})
+		h.Observe(0.5)
+		_ = r.Register(h)
+
+		s := NewSummary("size", SummaryConfig{})
+		s.Observe(3)
+		_ = r.Register(s)
+
+		_ = r.Register(NewGaugeVec("empty", []string{"shard"}))
+
+		samples, err := r.Collect()
+		if err != nil {
+			t.Fatalf("Collect() error = %v", err)
+		}
+
+		type result struct {
+			name   string
+			typ    MetricType
+			labels Labels
+			help   string
+			value  float64
+		}
+		var got []result
+		for _, s := range samples {
+			got = append(got, result{s.Name, s.Type, s.Labels, s.Help, s.Value})
+		}
+		want := []result{
+			{"latency", TypeHistogram, nil, "", 0},
+			{"requests_total", TypeCounter, Labels{"method": "GET"}, "", 1},
+			{"requests_total", TypeCounter, Labels{"method": "POST"}, "", 2},
+			{"size", TypeSummary, nil, "", 0},
+			{"temperature", TypeGauge, nil, "Current temperature.", 21.5},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Collect() = %+v, want %+v", got, want)
+		}
+
+		if samples[0].Histogram == nil || samples[0].Histogram.Count != 1 {
+			t.Errorf("histogram sample = %+v, want count 1", samples[0].Histogram)
+		}
+		if samples[3].Summary == nil || samples[3].Summary.Sum != 3 {
+			t.Errorf("summary sample = %+v, want sum 3", samples[3].Summary)
+		}
+		for _, s := range samples {
+			if s.Timestamp.IsZero() {
+				t.Errorf("sample %s has zero Timestamp", s.Name)
+			}
+		}
+	})
+
+	t.Run("carries created time and exemplar", func(t *testing.T) {
+		r := NewRegistry(0)
+		c := NewCounter("jobs_total")
+		_ = c.AddWithExemplar(1, Labels{"trace_id": "abc"})
+		_ = r.Register(c)
+
+		samples, _ := r.Collect()
+		if len(samples) != 1 {
+			t.Fatalf("Collect() returned %d samples, want 1", len(samples))
+		}
+		s := samples[0]
+		if !s.Created.Equal(c.Created()) {
+			t.Errorf("Created = %v, want %v", s.Created, c.Created())
+		}
+		if s.Exemplar == nil || s.Exemplar.Labels["trace_id"] != "abc" {
+			t.Errorf("Exemplar = %+v, want trace_id abc", s.Exemplar)
+		}
+	})
+
+	t.Run("reports unsupported values and keeps the rest", func(t *testing.T) {
+		r := NewRegistry(0)
+		_ = r.Register(unsupportedMetric{})
+		_ = r.Register(NewCounter("ok"))
+
+		samples, err := r.Collect()
+		if err == nil {
+			t.Error("Collect() with unsupported value should return error")
+		}
+		if len(samples) != 1 || samples[0].Name != "ok" {
+			t.Errorf("Collect() = %+v, want only ok", samples)
+		}
+	})
+
+	t.Run("zero value registry", func(t *testing.T) {
+		var r Registry
+
+		samples, err := r.Collect()
+		if err != nil || len(samples) != 0 {
+			t.Errorf("Collect() = %v, %v, want empty", samples, err)
+		}
+	})
+}
+
+// TestRegistry_Snapshot_Types tests that the Snapshot shim keeps its
+// historical value types.
+func TestRegistry_Snapshot_Types(t *testing.T) {
+	r := NewRegistry(0)
+	c := NewCounter("count")
+	c.Add(3)
+	_ = r.Register(c)
+	g := NewGauge("gauge")
+	g.Set(3)
+	_ = r.Register(g)
+	_ = r.Register(NewHistogram("hist", []float64{1}))
+
+	snapshot := r.Snapshot()
+	if _, ok := snapshot["count"].(int64); !ok {
+		t.Errorf("counter snapshot type = %T, want int64", snapshot["count"])
+	}
+	if _, ok := snapshot["gauge"].(float64); !ok {
+		t.Errorf("gauge snapshot type = %T, want float64", snapshot["gauge"])
+	}
+	if _, ok := snapshot["hist"].(HistogramSnapshot); !ok {
+		t.Errorf("histogram snapshot type = %T, want HistogramSnapshot", snapshot["hist"])
+	}
+}
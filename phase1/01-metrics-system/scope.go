@@ -0,0 +1,129 @@
+package metrics
+
+// scopeSeparator joins the name of a scope and its sub-scopes and metrics.
+const scopeSeparator = "_"
+
+// Scope is a view of a Registry that prefixes metric names and attaches
+// tags, so that each module of a program can own a namespace:
+//
+//	root := metrics.NewScope(registry)
+//	http := root.SubScope("http").Tagged(metrics.Labels{"service": "api"})
+//	requests, err := http.Counter("requests_total")
+//	// registers http_requests_total{service="api"}
+//
+// Metrics are created on first use and shared by every scope that asks for
+// the same name and tags. Tagged metrics are registered as vectors whose
+// label names are the tag names, so all scopes that use a name must use the
+// same set of tag names.
+//
+// A Scope is immutable and safe for concurrent use.
+type Scope struct {
+	registry *Registry
+	prefix   string
+	tags     Labels
+}
+
+// NewScope returns the root scope of r, which has no prefix and no tags.
+func NewScope(r *Registry) *Scope {
+	return &Scope{registry: r}
+}
+
+// SubScope returns a scope whose metric names are prefixed with name,
+// joined to the names of s by an underscore. It inherits the tags of s.
+func (s *Scope) SubScope(name string) *Scope {
+	if name == "" {
+		return s
+	}
+	return &Scope{
+		registry: s.registry,
+		prefix:   s.fullName(name),
+		tags:     s.tags,
+	}
+}
+
+// Tagged returns a scope with the tags of s and the given tags, which take
+// precedence over inherited tags with the same name. Tag names must be
+// valid label names; metrics of a scope with invalid tag names cannot be
+// created and return an error wrapping ErrInvalidLabelName.
+func (s *Scope) Tagged(tags Labels) *Scope {
+	if len(tags) == 0 {
+		return s
+	}
+	return &Scope{
+		registry: s.registry,
+		prefix:   s.prefix,
+		tags:     mergeLabels(s.tags, tags.clone()),
+	}
+}
+
+// Tags returns a copy of the tags of this scope.
+func (s *Scope) Tags() Labels {
+	return s.tags.clone()
+}
+
+// Counter returns the counter with the given name in this scope, creating
+// and registering it if needed. Options only apply when it is created.
+// It returns ErrMetricTypeMismatch if the name is registered to another
+// kind of metric and ErrLabelMismatch if it is registered with other tag
+// names.
+func (s *Scope) Counter(name string, opts ...Option) (*Counter, error) {
+	name = s.fullName(name)
+	if len(s.tags) == 0 {
+		return s.registry.GetOrRegisterCounter(name, opts...)
+	}
+
+	vec, err := getOrRegister(s.registry, name, func() *CounterVec {
+		return NewCounterVec(name, s.tags.names(), opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vec.With(s.tags)
+}
+
+// Gauge returns the gauge with the given name in this scope, creating and
+// registering it if needed. Options only apply when it is created.
+// It returns the same errors as Counter.
+func (s *Scope) Gauge(name string, opts ...Option) (*Gauge, error) {
+	name = s.fullName(name)
+	if len(s.tags) == 0 {
+		return s.registry.GetOrRegisterGauge(name, opts...)
+	}
+
+	vec, err := getOrRegister(s.registry, name, func() *GaugeVec {
+		return NewGaugeVec(name, s.tags.names(), opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vec.With(s.tags)
+}
+
+// Histogram returns the histogram with the given name in this scope,
+// creating and registering it with the given buckets if needed. Buckets
+// and options only apply when it is created.
+// It returns the same errors as Counter.
+func (s *Scope) Histogram(name string, buckets []float64, opts ...Option) (*Histogram, error) {
+	name = s.fullName(name)
+	if len(s.tags) == 0 {
+		return getOrRegister(s.registry, name, func() *Histogram {
+			return NewHistogram(name, buckets, opts...)
+		})
+	}
+
+	vec, err := getOrRegister(s.registry, name, func() *HistogramVec {
+		return NewHistogramVec(name, s.tags.names(), buckets, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vec.With(s.tags)
+}
+
+// fullName returns name prefixed with the prefix of s.
+func (s *Scope) fullName(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + scopeSeparator + name
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestScope tests scoped metric creation.
+func TestScope(t *testing.T) {
+	t.Run("prefixes names and applies tags", func(t *testing.T) {
+		r := NewRegistry(0)
+		root := NewScope(r)
+
+		c, err := root.SubScope("http").Tagged(Labels{"service": "api"}).Counter("requests_total")
+		if err != nil {
+			t.Fatalf("Counter() error = %v", err)
+		}
+		c.Add(2)
+
+		g, err := root.SubScope("db").SubScope("pool").Gauge("open_connections")
+		if err != nil {
+			t.Fatalf("Gauge() error = %v", err)
+		}
+		g.Set(4)
+
+		h, err := root.SubScope("http").Histogram("duration_seconds", []float64{1})
+		if err != nil {
+			t.Fatalf("Histogram() error = %v", err)
+		}
+		h.Observe(0.5)
+
+		var buf bytes.Buffer
+		if err := WritePrometheus(&buf, r); err != nil {
+			t.Fatalf("WritePrometheus() error = %v", err)
+		}
+		want := `# TYPE db_pool_open_connections gauge
+db_pool_open_connections 4
+# TYPE http_duration_seconds histogram
+http_duration_seconds_bucket{le="1"} 1
+http_duration_seconds_bucket{le="+Inf"} 1
+http_duration_seconds_sum 0.5
+http_duration_seconds_count 1
+# TYPE http_requests_total counter
+http_requests_total{service="api"} 2
+`
+		if got := buf.String(); got != want {
+			t.Errorf("output =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("same name and tags share a metric", func(t *testing.T) {
+		root := NewScope(NewRegistry(0))
+		api := root.SubScope("http").Tagged(Labels{"service": "api"})
+
+		c1, _ := api.Counter("requests_total")
+		c2, _ := root.Tagged(Labels{"service": "api"}).SubScope("http").Counter("requests_total")
+		if c1 != c2 {
+			t.Error("equivalent scopes returned different counters")
+		}
+
+		other, _ := root.SubScope("http").Tagged(Labels{"service": "admin"}).Counter("requests_total")
+		if other == c1 {
+			t.Error("scopes with different tag values returned the same counter")
+		}
+	})
+
+	t.Run("tags are inherited and overridden", func(t *testing.T) {
+		base := NewScope(NewRegistry(0)).Tagged(Labels{"service": "api", "region": "eu"})
+		scoped := base.SubScope("jobs").Tagged(Labels{"region": "us"})
+
+		want := Labels{"service": "api", "region": "us"}
+		if got := scoped.Tags(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Tags() = %v, want %v", got, want)
+		}
+		if got := base.Tags()["region"]; got != "eu" {
+			t.Errorf("parent tag region = %q, want eu", got)
+		}
+	})
+
+	t.Run("tags are copied", func(t *testing.T) {
+		tags := Labels{"service": "api"}
+		s := NewScope(NewRegistry(0)).Tagged(tags)
+		tags["service"] = "changed"
+
+		if got := s.Tags()["service"]; got != "api" {
+			t.Errorf("Tags()[service] = %q, want api", got)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		r := NewRegistry(0, WithNamingPolicy(PrometheusNamingPolicy()))
+		root := NewScope(r)
+		_, _ = root.Gauge("in_flight")
+		_, _ = root.Tagged(Labels{"service": "api"}).Counter("requests_total")
+
+		if _, err := root.Counter("in_flight"); !errors.Is(err, ErrMetricTypeMismatch) {
+			t.Errorf("Counter() on gauge name error = %v, want ErrMetricTypeMismatch", err)
+		}
+		if _, err := root.Counter("requests_total"); !errors.Is(err, ErrMetricTypeMismatch) {
+			t.Errorf("untagged Counter() on tagged name error = %v, want ErrMetricTypeMismatch", err)
+		}
+		if _, err := root.Tagged(Labels{"zone": "a"}).Counter("requests_total"); !errors.Is(err, ErrLabelMismatch) {
+			t.Errorf("Counter() with other tag names error = %v, want ErrLabelMismatch", err)
+		}
+		if _, err := root.SubScope("bad name").Gauge("x"); !errors.Is(err, ErrInvalidMetricName) {
+			t.Errorf("Gauge() with invalid name error = %v, want ErrInvalidMetricName", err)
+		}
+		if _, err := root.Tagged(Labels{"bad-tag": "a"}).Counter("tagged_total"); !errors.Is(err, ErrInvalidLabelName) {
+			t.Errorf("Counter() with invalid tag name error = %v, want ErrInvalidLabelName", err)
+		}
+	})
+
+	t.Run("empty sub-scope and tags return the same scope", func(t *testing.T) {
+		root := NewScope(NewRegistry(0))
+
+		if root.SubScope("") != root {
+			t.Error("SubScope(\"\") returned a new scope")
+		}
+		if root.Tagged(nil) != root {
+			t.Error("Tagged(nil) returned a new scope")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// cacheLinePadSize is the size cells are padded to. It covers the 128-byte
+// lines, or pairs of 64-byte lines fetched together, of common platforms.
+const cacheLinePadSize = 128
+
+// counterCell is a counter shard that occupies cache lines of its own, so
+// that concurrent increments of different cells do not contend.
+type counterCell struct {
+	value atomic.Int64
+	_     [cacheLinePadSize - 8]byte
+}
+
+// ShardedCounter is a counter for hot paths incremented from many
+// goroutines at once. It spreads increments across cells on separate cache
+// lines, so that cores do not contend for the single line of a Counter,
+// and sums the cells when read. Increments are cheaper than those of a
+// Counter under contention, reads are more expensive, and each counter
+// holds two to four 128-byte cells per GOMAXPROCS.
+//
+// A ShardedCounter is a counter metric like any other: it can be
+// registered, exported and expired in place of a Counter.
+// It is safe for concurrent use. The zero value is ready to use.
+type ShardedCounter struct {
+	name    string
+	desc    *Desc
+	labels  Labels
+	created time.Time
+	cells   atomic.Pointer[[]counterCell] // allocated on first use
+	updateTracker
+}
+
+// Compile-time verification that ShardedCounter implements Metric interface.
+var _ Metric = (*ShardedCounter)(nil)
+
+// NewShardedCounter creates a new sharded counter metric with the given name
+// and options. The counter starts at 0 and can only be incremented.
+func NewShardedCounter(name string, opts ...Option) *ShardedCounter {
+	desc := newDesc(name, TypeCounter, nil, opts)
+	c := &ShardedCounter{
+		name:    name,
+		desc:    &desc,
+		labels:  desc.ConstLabels,
+		created: time.Now(),
+	}
+	c.loadCells()
+	return c
+}
+
+// Name returns the name of this counter metric.
+func (c *ShardedCounter) Name() string {
+	return c.name
+}
+
+// Desc returns the descriptor of this counter.
+func (c *ShardedCounter) Desc() Desc {
+	return describe(c.name, TypeCounter, c.desc)
+}
+
+// Labels returns a copy of the constant labels of this counter.
+func (c *ShardedCounter) Labels() Labels {
+	return c.labels.clone()
+}
+
+// Created returns the time the counter was created, or the zero time for a
+// zero-value ShardedCounter.
+func (c *ShardedCounter) Created() time.Time {
+	return c.created
+}
+
+// Type returns TypeCounter, indicating this is a counter metric.
+func (c *ShardedCounter) Type() MetricType {
+	return TypeCounter
+}
+
+// Value returns the current value of the counter as an interface{}.
+// The underlying type is int64.
+func (c *ShardedCounter) Value() interface{} {
+	return c.Load()
+}
+
+// Inc increments the counter by 1.
+// This operation is atomic and safe for concurrent use.
+func (c *ShardedCounter) Inc() {
+	c.Add(1)
+}
+
+// Add increments the counter by the given delta.
+// Delta must be non-negative. Negative values are treated as 0.
+// This operation is atomic and safe for concurrent use.
+func (c *ShardedCounter) Add(delta int64) {
+	if delta < 0 {
+		delta = 0
+	}
+
+	// A random cell spreads goroutines across cells without any shared
+	// state; the generator is per-thread in the runtime.
+	cells := c.loadCells()
+	cells[rand.Uint64()&uint64(len(cells)-1)].value.Add(delta)
+	c.touch()
+}
+
+// Load returns the current value of the counter, the sum of its cells.
+// It does not block increments. A Load concurrent with increments may miss
+// some of them, but never returns less than a Load that happened before it.
+func (c *ShardedCounter) Load() int64 {
+	var sum int64
+	cells := c.loadCells()
+	for i := range cells {
+		sum += cells[i].value.Load()
+	}
+	return sum
+}
+
+// loadCells returns the cells of the counter, allocating them on first use.
+func (c *ShardedCounter) loadCells() []counterCell {
+	if cells := c.cells.Load(); cells != nil {
+		return *cells
+	}
+
+	cells := make([]counterCell, shardCount())
+	if c.cells.CompareAndSwap(nil, &cells) {
+		return cells
+	}
+	return *c.cells.Load()
+}
+
+// shardCount returns the number of cells of a new ShardedCounter: twice
+// GOMAXPROCS, to make collisions between randomly chosen cells rare,
+// rounded up to a power of two so that a cell can be chosen with a mask.
+func shardCount() int {
+	n := 2 * runtime.GOMAXPROCS(0)
+	return 1 << bits.Len(uint(n-1))
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// TestShardedCounter tests increments and reads.
+func TestShardedCounter(t *testing.T) {
+	tests := []struct {
+		name    string
+		counter *ShardedCounter
+	}{
+		{"constructed", NewShardedCounter("requests_total")},
+		{"zero value", &ShardedCounter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.counter
+			if got := c.Load(); got != 0 {
+				t.Fatalf("Load() = %d, want 0", got)
+			}
+
+			c.Inc()
+			c.Add(41)
+			c.Add(-5) // ignored
+
+			if got := c.Load(); got != 42 {
+				t.Errorf("Load() = %d, want 42", got)
+			}
+			if got := c.Value(); got != int64(42) {
+				t.Errorf("Value() = %v (%T), want int64 42", got, got)
+			}
+			if got := c.Type(); got != TypeCounter {
+				t.Errorf("Type() = %v, want counter", got)
+			}
+		})
+	}
+}
+
+// TestShardedCounter_Concurrent tests that no increment is lost.
+func TestShardedCounter_Concurrent(t *testing.T) {
+	const goroutines, increments = 16, 1000
+
+	c := &ShardedCounter{}
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				c.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.Load(); got != goroutines*increments {
+		t.Errorf("Load() = %d, want %d", got, goroutines*increments)
+	}
+}
+
+// TestShardedCounter_Registry tests that a sharded counter is exported like
+// a Counter.
+func TestShardedCounter_Registry(t *testing.T) {
+	r := NewRegistry(0)
+	c := NewShardedCounter("hits_total", WithHelp("Cache hits."),
+		WithConstLabels(Labels{"cache": "users"}))
+	if err := r.Register(c); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	c.Add(7)
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, r); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+
+	want := `# HELP hits_total Cache hits.
+# TYPE hits_total counter
+hits_total{cache="users"} 7
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestShardCount tests that the number of cells is a power of two.
+func TestShardCount(t *testing.T) {
+	n := shardCount()
+	if n < 2 || n&(n-1) != 0 {
+		t.Errorf("shardCount() = %d, want a power of two >= 2", n)
+	}
+}
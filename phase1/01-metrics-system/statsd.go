@@ -0,0 +1,272 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStatsDInterval is the default interval between StatsD flushes.
+	DefaultStatsDInterval = 10 * time.Second
+
+	// DefaultStatsDPacketSize is the default maximum UDP payload size. It
+	// fits a typical 1500-byte Ethernet MTU after IP and UDP headers.
+	DefaultStatsDPacketSize = 1432
+)
+
+// statsDForgetAfter is the number of consecutive reports a counter may be
+// missing from before a StatsDReporter forgets its previous value. Reports
+// do not say whether their collection failed, so a counter is not
+// forgotten on a single miss: its next delta would be its whole value.
+const statsDForgetAfter = 5
+
+// StatsDConfig configures a StatsDReporter. Address is required; the other
+// fields default when zero.
+type StatsDConfig struct {
+	// Address is the host:port of the StatsD agent.
+	Address string
+
+	// Prefix is prepended to every metric name, separated by a dot.
+	Prefix string
+
+	// Interval is the time between flushes when running with Run.
+	// Defaults to DefaultStatsDInterval.
+	Interval time.Duration
+
+	// MaxPacketSize is the maximum payload of a single UDP packet. Lines
+	// are batched into packets up to this size. Defaults to
+	// DefaultStatsDPacketSize.
+	MaxPacketSize int
+
+	// DogStatsD sends labels as DogStatsD tags (|#key:value). Otherwise
+	// labels are folded into the metric name as .key.value segments.
+	DogStatsD bool
+}
+
+// StatsDReporter is a Reporter that sends counters and gauges to a StatsD
+// agent over UDP. Counters are sent as deltas since the previous report
+// (|c) and gauges as absolute values (|g). Meters are sent as counters of
+// their events. Other metric types are not sent.
+//
+// It can be used as a sink of a ReportLoop, or report its own registry
+// with Flush and Run.
+//
+// A StatsDReporter is safe for concurrent use.
+type StatsDReporter struct {
+	registry *Registry
+	cfg      StatsDConfig
+
+	mu     sync.Mutex
+	conn   net.Conn
+	deltas deltaTracker // of counters and meters
+	closed bool
+}
+
+// Compile-time verification that StatsDReporter implements Reporter.
+var _ Reporter = (*StatsDReporter)(nil)
+
+// NewStatsDReporter creates a reporter that sends the metrics of r to the
+// StatsD agent at cfg.Address. It returns an error if the address cannot
+// be resolved.
+func NewStatsDReporter(r *Registry, cfg StatsDConfig) (*StatsDReporter, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("statsd: address is required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultStatsDInterval
+	}
+	if cfg.MaxPacketSize <= 0 {
+		cfg.MaxPacketSize = DefaultStatsDPacketSize
+	}
+
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: dial %s: %w", cfg.Address, err)
+	}
+
+	return &StatsDReporter{
+		registry: r,
+		cfg:      cfg,
+		conn:     conn,
+		deltas:   deltaTracker{forgetAfter: statsDForgetAfter},
+	}, nil
+}
+
+// Run flushes every interval until ctx is cancelled, using a ReportLoop.
+// Flush errors are dropped; UDP delivery is best-effort anyway. It returns
+// an error if the loop cannot be created, and nil once ctx is cancelled.
+func (s *StatsDReporter) Run(ctx context.Context) error {
+	loop, err := NewReportLoop(s.registry, ReportLoopConfig{
+		Interval: s.cfg.Interval,
+		Sinks:    []ReportSink{{Name: "statsd", Reporter: s}},
+	})
+	if err != nil {
+		return fmt.Errorf("statsd: %w", err)
+	}
+	loop.Run(ctx)
+	return nil
+}
+
+// Report sends the counter deltas since the previous report and the gauge
+// values of samples.
+func (s *StatsDReporter) Report(ctx context.Context, samples []Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("statsd: reporter is closed")
+	}
+	return s.sendLocked(ctx, s.linesLocked(samples))
+}
+
+// Flush sends the current counter deltas and gauge values of the registry.
+func (s *StatsDReporter) Flush() error {
+	// Metrics that cannot be collected are skipped.
+	samples, _ := s.registry.Collect()
+	return s.Report(context.Background(), samples)
+}
+
+// Close flushes any pending values and closes the connection.
+func (s *StatsDReporter) Close() error {
+	samples, _ := s.registry.Collect()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.sendLocked(context.Background(), s.linesLocked(samples))
+	return errors.Join(err, s.conn.Close())
+}
+
+// linesLocked renders one StatsD line per counter, meter and gauge of
+// samples. s.mu must be held.
+func (s *StatsDReporter) linesLocked(samples []Sample) []string {
+	// The deltas are computed on a copy of the counters, the samples being
+	// shared with other reporters. A counter registered again starts from
+	// zero, its creation time having changed.
+	var counters []Sample
+	for _, sample := range samples {
+		if sample.Type == TypeCounter || sample.Type == TypeMeter {
+			counters = append(counters, sample)
+		}
+	}
+	s.deltas.apply(counters, false)
+
+	var lines []string
+	for _, sample := range samples {
+		switch sample.Type {
+		case TypeCounter, TypeMeter:
+			sample, counters = counters[0], counters[1:]
+			delta := int64(sample.Value)
+			if sample.Meter != nil {
+				delta = sample.Meter.Count
+			}
+			if delta != 0 {
+				lines = append(lines, s.line(sample.Name, sample.Labels, strconv.FormatInt(delta, 10), "c")...)
+			}
+		case TypeGauge:
+			value := strconv.FormatFloat(sample.Value, 'f', -1, 64)
+			lines = append(lines, s.line(sample.Name, sample.Labels, value, "g")...)
+		}
+	}
+
+	return lines
+}
+
+// line renders a metric as StatsD lines. A negative gauge is sent as a
+// reset to zero followed by the value, since a leading sign would make
+// StatsD treat it as a relative change.
+func (s *StatsDReporter) line(name string, labels Labels, value, kind string) []string {
+	var b strings.Builder
+	if s.cfg.Prefix != "" {
+		b.WriteString(sanitizeStatsD(s.cfg.Prefix))
+		b.WriteByte('.')
+	}
+	b.WriteString(sanitizeStatsD(name))
+	if !s.cfg.DogStatsD {
+		for _, k := range labels.names() {
+			b.WriteByte('.')
+			b.WriteString(sanitizeStatsD(k))
+			b.WriteByte('.')
+			b.WriteString(sanitizeStatsD(labels[k]))
+		}
+	}
+	prefix := b.String()
+
+	var tags string
+	if s.cfg.DogStatsD && len(labels) > 0 {
+		pairs := make([]string, 0, len(labels))
+		for _, k := range labels.names() {
+			pairs = append(pairs, sanitizeStatsD(k)+":"+sanitizeStatsD(labels[k]))
+		}
+		tags = "|#" + strings.Join(pairs, ",")
+	}
+
+	render := func(v string) string {
+		return prefix + ":" + v + "|" + kind + tags
+	}
+	if kind == "g" && strings.HasPrefix(value, "-") {
+		return []string{render("0"), render(value)}
+	}
+	return []string{render(value)}
+}
+
+// sendLocked batches lines into packets of at most MaxPacketSize bytes and
+// writes them until ctx is done. A line longer than the limit is sent on
+// its own. s.mu must be held.
+func (s *StatsDReporter) sendLocked(ctx context.Context, lines []string) error {
+	deadline, _ := ctx.Deadline()
+	_ = s.conn.SetWriteDeadline(deadline)
+
+	var errs []error
+	var packet strings.Builder
+
+	flush := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			packet.Reset()
+			return
+		}
+		if _, err := s.conn.Write([]byte(packet.String())); err != nil {
+			errs = append(errs, err)
+		}
+		packet.Reset()
+	}
+
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > s.cfg.MaxPacketSize {
+			flush()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	flush()
+
+	return errors.Join(errs...)
+}
+
+// statsDReplacer replaces characters that are significant in the StatsD
+// line protocol.
+var statsDReplacer = strings.NewReplacer(
+	":", "_", "|", "_", "@", "_", "#", "_", ",", "_",
+	" ", "_", "\n", "_", "\t", "_",
+)
+
+func sanitizeStatsD(s string) string {
+	return statsDReplacer.Replace(s)
+}
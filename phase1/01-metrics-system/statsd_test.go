@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// udpListener is a local stand-in for a StatsD agent.
+type udpListener struct {
+	t    *testing.T
+	conn net.PacketConn
+}
+
+func newUDPListener(t *testing.T) *udpListener {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &udpListener{t: t, conn: conn}
+}
+
+func (l *udpListener) addr() string {
+	return l.conn.LocalAddr().String()
+}
+
+// packets reads packets until none arrives within a short wait.
+func (l *udpListener) packets() []string {
+	l.t.Helper()
+
+	var packets []string
+	buf := make([]byte, 65536)
+	for {
+		wait := 200 * time.Millisecond
+		if len(packets) == 0 {
+			wait = 2 * time.Second
+		}
+		_ = l.conn.SetReadDeadline(time.Now().Add(wait))
+
+		n, _, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			return packets
+		}
+		packets = append(packets, string(buf[:n]))
+	}
+}
+
+// lines reads packets and splits them into lines.
+func (l *udpListener) lines() []string {
+	l.t.Helper()
+
+	var lines []string
+	for _, p := range l.packets() {
+		lines = append(lines, strings.Split(p, "\n")...)
+	}
+	return lines
+}
+
+func newTestStatsDReporter(t *testing.T, r *Registry, cfg StatsDConfig) *StatsDReporter {
+	t.Helper()
+
+	s, err := NewStatsDReporter(r, cfg)
+	if err != nil {
+		t.Fatalf("NewStatsDReporter() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestStatsDReporter tests the StatsD wire output.
+func TestStatsDReporter(t *testing.T) {
+	t.Run("sends counter deltas and gauge values", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		c := NewCounter("requests")
+		g := NewGauge("temperature")
+		_ = r.Register(c)
+		_ = r.Register(g)
+
+		s := newTestStatsDReporter(t, r, StatsDConfig{Address: l.addr(), Prefix: "app"})
+
+		c.Add(5)
+		g.Set(21.5)
+		if err := s.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+		if got, want := l.lines(), []string{"app.requests:5|c", "app.temperature:21.5|g"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("first flush = %v, want %v", got, want)
+		}
+
+		c.Add(2)
+		if err := s.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+		if got, want := l.lines(), []string{"app.requests:2|c", "app.temperature:21.5|g"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("second flush = %v, want %v", got, want)
+		}
+
+		if err := s.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+		if got, want := l.lines(), []string{"app.temperature:21.5|g"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("flush without counter changes = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("treats counter decrease as reset", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		c := NewCounter("jobs")
+		c.Add(10)
+		_ = r.Register(c)
+
+		s := newTestStatsDReporter(t, r, StatsDConfig{Address: l.addr()})
+		_ = s.Flush()
+		l.lines()
+
+		_ = r.Unregister("jobs")
+		replacement := NewCounter("jobs")
+		replacement.Add(3)
+		_ = r.Register(replacement)
+
+		_ = s.Flush()
+		if got, want := l.lines(), []string{"jobs:3|c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("flush after reset = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("folds labels into names", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		v := NewCounterVec("http_requests", []string{"status", "method"})
+		c, _ := v.WithLabelValues("200", "GET")
+		c.Inc()
+		_ = r.Register(v)
+
+		s := newTestStatsDReporter(t, r, StatsDConfig{Address: l.addr()})
+		_ = s.Flush()
+
+		if got, want := l.lines(), []string{"http_requests.method.GET.status.200:1|c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("lines = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("sends DogStatsD tags", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		v := NewGaugeVec("queue_depth", []string{"queue", "region"})
+		g, _ := v.WithLabelValues("jobs", "eu:west")
+		g.Set(7)
+		_ = r.Register(v)
+
+		s := newTestStatsDReporter(t, r, StatsDConfig{Address: l.addr(), DogStatsD: true})
+		_ = s.Flush()
+
+		if got, want := l.lines(), []string{"queue_depth:7|g|#queue:jobs,region:eu_west"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("lines = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("resets before negative gauges", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		g := NewGauge("balance")
+		g.Set(-4)
+		_ = r.Register(g)
+
+		s := newTestStatsDReporter(t, r, StatsDConfig{Address: l.addr()})
+		_ = s.Flush()
+
+		if got, want := l.lines(), []string{"balance:0|g", "balance:-4|g"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("lines = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("batches lines up to packet size", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			g := NewGauge(name)
+			g.Set(1)
+			_ = r.Register(g)
+		}
+
+		// Each line is 5 bytes ("a:1|g"), so two fit with a separator.
+		s := newTestStatsDReporter(t, r, StatsDConfig{Address: l.addr(), MaxPacketSize: 11})
+		_ = s.Flush()
+
+		want := []string{"a:1|g\nb:1|g", "c:1|g\nd:1|g", "e:1|g"}
+		if got := l.packets(); !reflect.DeepEqual(got, want) {
+			t.Errorf("packets = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Close flushes and rejects further flushes", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		c := NewCounter("shutdown")
+		_ = r.Register(c)
+
+		s, err := NewStatsDReporter(r, StatsDConfig{Address: l.addr()})
+		if err != nil {
+			t.Fatalf("NewStatsDReporter() error = %v", err)
+		}
+
+		c.Inc()
+		if err := s.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if got, want := l.lines(), []string{"shutdown:1|c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("lines on Close = %v, want %v", got, want)
+		}
+
+		if err := s.Flush(); err == nil {
+			t.Error("Flush() after Close() should return error")
+		}
+		if err := s.Close(); err != nil {
+			t.Errorf("second Close() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("collector failure does not resend totals", func(t *testing.T) {
+		l := newUDPListener(t)
+		r := NewRegistry(0)
+		total, failing := 1000.0, false
+		_ = r.RegisterCollector(&stubCollector{
+			descs: []Desc{{Name: "jobs_total", Type: TypeCounter}},
+			collect: func(context.Context) ([]Sample, error) {
+				if failing {
+					return nil, errors.New("backend down")
+				}
+				return []Sample{{Name: "jobs_total", Value: total}}, nil
+			},
+		})
+		s := newTestStatsDReporter(t, r, StatsDConfig{Address: l.addr()})
+
+		steps := []struct {
+			name    string
+			total   float64
+			failing bool
+			want    []string
+		}{
+			{name: "first", total: 1000, want: []string{"jobs_total:1000|c"}},
+			{name: "increase", total: 1001, want: []string{"jobs_total:1|c"}},
+			{name: "failure", total: 1001, failing: true, want: nil},
+			{name: "recovery", total: 1002, want: []string{"jobs_total:1|c"}},
+		}
+		for _, step := range steps {
+			total, failing = step.total, step.failing
+			_ = s.Flush()
+			if got := l.lines(); !reflect.DeepEqual(got, step.want) {
+				t.Errorf("%s flush = %v, want %v", step.name, got, step.want)
+			}
+		}
+	})
+
+	t.Run("requires address", func(t *testing.T) {
+		if _, err := NewStatsDReporter(NewRegistry(0), StatsDConfig{}); err == nil {
+			t.Error("NewStatsDReporter() without address should return error")
+		}
+	})
+}
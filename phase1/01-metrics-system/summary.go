@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	// DefaultMaxAge is the default sliding window over which a Summary
+	// computes its quantiles.
+	DefaultMaxAge = 10 * time.Minute
+
+	// DefaultAgeBuckets is the default number of buckets the sliding window
+	// of a Summary is divided into.
+	DefaultAgeBuckets = 5
+)
+
+// SummaryConfig configures a Summary. The zero value selects the defaults.
+type SummaryConfig struct {
+	// Objectives maps each tracked quantile to its allowed absolute error.
+	// Quantiles must be in (0, 1) and errors in (0, 1]; other entries are
+	// ignored, since an exact quantile would need unbounded memory. If no
+	// entry is valid, p50, p90 and p99 are tracked with errors of 0.05,
+	// 0.01 and 0.001.
+	Objectives map[float64]float64
+
+	// MaxAge is the duration for which observations are kept for quantile
+	// computation. Defaults to DefaultMaxAge.
+	MaxAge time.Duration
+
+	// AgeBuckets is the number of buckets the window is divided into. The
+	// window slides forward one bucket at a time. Defaults to DefaultAgeBuckets.
+	AgeBuckets int
+
+	// Clock is the time source for the sliding window.
+	// Defaults to the system clock.
+	Clock Clock
+}
+
+// Quantile is a single estimated quantile value.
+type Quantile struct {
+	// Quantile is the rank in [0, 1], e.g. 0.99 for p99.
+	Quantile float64
+
+	// Value is the estimated value at that rank, or NaN if there were no
+	// observations within the window.
+	Value float64
+}
+
+// SummarySnapshot is a point-in-time copy of a summary's state.
+// Quantiles cover the sliding window only; Count and Sum cover all
+// observations since the summary was created.
+type SummarySnapshot struct {
+	Quantiles []Quantile
+	Count     uint64
+	Sum       float64
+}
+
+// Summary is a metric that estimates quantiles of observations over a
+// sliding time window. It uses bounded memory regardless of the number of
+// observations. It is safe for concurrent use by multiple goroutines.
+// The zero value is ready to use with the default configuration.
+type Summary struct {
+	name    string
+	desc    *Desc
+	labels  Labels
+	created time.Time
+	count   atomic.Uint64
+	sum     atomic.Float64
+	updateTracker
+
+	mu             sync.Mutex
+	cfg            SummaryConfig
+	initialized    bool
+	objectives     []objective
+	clock          Clock
+	streams        []*quantileStream
+	head           int
+	headExpiry     time.Time
+	streamDuration time.Duration
+}
+
+// Compile-time verification that Summary implements Metric interface.
+var _ Metric = (*Summary)(nil)
+
+// NewSummary creates a new summary metric with the given name,
+// configuration and options.
+func NewSummary(name string, cfg SummaryConfig, opts ...Option) *Summary {
+	desc := newDesc(name, TypeSummary, nil, opts)
+	return newSummary(&desc, desc.ConstLabels, cfg)
+}
+
+// newSummary creates a summary with the given descriptor and labels.
+func newSummary(desc *Desc, labels Labels, cfg SummaryConfig) *Summary {
+	return &Summary{
+		name:    desc.Name,
+		desc:    desc,
+		labels:  labels,
+		created: clockOrDefault(cfg.Clock).Now(),
+		cfg:     cfg,
+	}
+}
+
+// Name returns the name of this summary metric.
+func (s *Summary) Name() string {
+	return s.name
+}
+
+// Desc returns the descriptor of this summary.
+func (s *Summary) Desc() Desc {
+	return describe(s.name, TypeSummary, s.desc)
+}
+
+// Labels returns a copy of the labels of this summary: its constant labels
+// and, for children of a SummaryVec, its variable labels.
+func (s *Summary) Labels() Labels {
+	return s.labels.clone()
+}
+
+// Created returns the time the summary was created, or the zero time for a
+// zero-value Summary.
+func (s *Summary) Created() time.Time {
+	return s.created
+}
+
+// Type returns TypeSummary, indicating this is a summary metric.
+func (s *Summary) Type() MetricType {
+	return TypeSummary
+}
+
+// Value returns the current state of the summary as an interface{}.
+// The underlying type is SummarySnapshot.
+func (s *Summary) Value() interface{} {
+	return s.Snapshot()
+}
+
+// Observe adds a single observation to the summary.
+// This operation is safe for concurrent use.
+func (s *Summary) Observe(value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initLocked()
+	s.rotateLocked()
+	for _, stream := range s.streams {
+		stream.insert(value)
+	}
+
+	s.count.Inc()
+	s.sum.Add(value)
+	s.touch()
+}
+
+// Snapshot returns the estimated quantiles over the sliding window together
+// with the total count and sum, with quantiles sorted in ascending order.
+func (s *Summary) Snapshot() SummarySnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initLocked()
+	s.rotateLocked()
+
+	head := s.streams[s.head]
+	quantiles := make([]Quantile, len(s.objectives))
+	for i, o := range s.objectives {
+		quantiles[i] = Quantile{
+			Quantile: o.quantile,
+			Value:    head.query(o.quantile),
+		}
+	}
+
+	return SummarySnapshot{
+		Quantiles: quantiles,
+		Count:     s.count.Load(),
+		Sum:       s.sum.Load(),
+	}
+}
+
+// initLocked applies defaults on first use. s.mu must be held.
+func (s *Summary) initLocked() {
+	if s.initialized {
+		return
+	}
+	s.initialized = true
+
+	s.objectives = summaryObjectives(s.cfg.Objectives)
+
+	maxAge := s.cfg.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	ageBuckets := s.cfg.AgeBuckets
+	if ageBuckets <= 0 {
+		ageBuckets = DefaultAgeBuckets
+	}
+
+	s.clock = clockOrDefault(s.cfg.Clock)
+	s.streamDuration = maxAge / time.Duration(ageBuckets)
+	s.headExpiry = s.clock.Now().Add(s.streamDuration)
+	s.streams = make([]*quantileStream, ageBuckets)
+	for i := range s.streams {
+		s.streams[i] = newQuantileStream(s.objectives)
+	}
+}
+
+// summaryObjectives returns the valid entries of objectives sorted by
+// quantile, or the default objectives if there are none.
+func summaryObjectives(objectives map[float64]float64) []objective {
+	var valid []objective
+	for q, eps := range objectives {
+		if q <= 0 || q >= 1 || eps <= 0 || eps > 1 {
+			continue
+		}
+		valid = append(valid, objective{quantile: q, epsilon: eps})
+	}
+	if len(valid) == 0 {
+		valid = []objective{{quantile: 0.5, epsilon: 0.05}, {quantile: 0.9, epsilon: 0.01}, {quantile: 0.99, epsilon: 0.001}}
+	}
+	sort.Slice(valid, func(i, j int) bool {
+		return valid[i].quantile < valid[j].quantile
+	})
+	return valid
+}
+
+// rotateLocked advances the sliding window. Every stream receives every
+// observation, but each is reset once per window at staggered times; the
+// head is always the oldest stream and therefore covers between
+// MaxAge-MaxAge/AgeBuckets and MaxAge of history. s.mu must be held.
+func (s *Summary) rotateLocked() {
+	now := s.clock.Now()
+	for !now.Before(s.headExpiry) {
+		s.streams[s.head].reset()
+		s.head = (s.head + 1) % len(s.streams)
+		s.headExpiry = s.headExpiry.Add(s.streamDuration)
+	}
+}
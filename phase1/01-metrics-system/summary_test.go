@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSummary tests the Summary implementation.
+func TestSummary(t *testing.T) {
+	t.Run("zero value is usable", func(t *testing.T) {
+		var s Summary
+		s.name = "test"
+
+		s.Observe(1)
+		s.Observe(2)
+		s.Observe(3)
+
+		got := s.Snapshot()
+		if got.Count != 3 {
+			t.Errorf("zero value Summary count = %v, want 3", got.Count)
+		}
+		if got.Sum != 6 {
+			t.Errorf("zero value Summary sum = %v, want 6", got.Sum)
+		}
+
+		wantQuantiles := []float64{0.5, 0.9, 0.99}
+		if len(got.Quantiles) != len(wantQuantiles) {
+			t.Fatalf("zero value Summary has %d quantiles, want %d", len(got.Quantiles), len(wantQuantiles))
+		}
+		for i, q := range got.Quantiles {
+			if q.Quantile != wantQuantiles[i] {
+				t.Errorf("Quantiles[%d].Quantile = %v, want %v", i, q.Quantile, wantQuantiles[i])
+			}
+		}
+		if got.Quantiles[0].Value != 2 {
+			t.Errorf("p50 of {1,2,3} = %v, want 2", got.Quantiles[0].Value)
+		}
+	})
+
+	t.Run("NewSummary creates summary with name", func(t *testing.T) {
+		s := NewSummary("test_summary", SummaryConfig{})
+
+		if got := s.Name(); got != "test_summary" {
+			t.Errorf("Summary.Name() = %v, want test_summary", got)
+		}
+
+		if got := s.Type(); got != TypeSummary {
+			t.Errorf("Summary.Type() = %v, want %v", got, TypeSummary)
+		}
+	})
+
+	t.Run("empty window reports NaN", func(t *testing.T) {
+		s := NewSummary("test", SummaryConfig{})
+
+		for _, q := range s.Snapshot().Quantiles {
+			if !math.IsNaN(q.Value) {
+				t.Errorf("empty Summary quantile %v = %v, want NaN", q.Quantile, q.Value)
+			}
+		}
+	})
+
+	t.Run("invalid objectives are ignored", func(t *testing.T) {
+		s := NewSummary("test", SummaryConfig{
+			Objectives: map[float64]float64{0.5: 0.05, 1.5: 0.01, 0.9: -1},
+		})
+
+		got := s.Snapshot().Quantiles
+		if len(got) != 1 || got[0].Quantile != 0.5 {
+			t.Errorf("Summary quantiles = %+v, want only 0.5", got)
+		}
+	})
+
+	t.Run("objectives are validated", func(t *testing.T) {
+		defaults := []float64{0.5, 0.9, 0.99}
+		tests := []struct {
+			name       string
+			objectives map[float64]float64
+			want       []float64
+		}{
+			{name: "valid", objectives: map[float64]float64{0.9: 0.01, 0.5: 0.05}, want: []float64{0.5, 0.9}},
+			{name: "empty", objectives: nil, want: defaults},
+			{name: "quantile 0", objectives: map[float64]float64{0: 0.01, 0.5: 0.05}, want: []float64{0.5}},
+			{name: "quantile 1", objectives: map[float64]float64{1: 0.01, 0.5: 0.05}, want: []float64{0.5}},
+			{name: "quantile out of range", objectives: map[float64]float64{1.5: 0.01, 0.5: 0.05}, want: []float64{0.5}},
+			{name: "zero error", objectives: map[float64]float64{0.9: 0, 0.5: 0.05}, want: []float64{0.5}},
+			{name: "negative error", objectives: map[float64]float64{0.9: -1, 0.5: 0.05}, want: []float64{0.5}},
+			{name: "error above 1", objectives: map[float64]float64{0.9: 2, 0.5: 0.05}, want: []float64{0.5}},
+			{name: "none valid", objectives: map[float64]float64{0: 0.01, 1: 0.01, 0.5: 0}, want: defaults},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var got []float64
+				for _, o := range summaryObjectives(tt.objectives) {
+					got = append(got, o.quantile)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("summaryObjectives(%v) quantiles = %v, want %v", tt.objectives, got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("memory stays bounded with invalid objectives", func(t *testing.T) {
+		s := NewSummary("test", SummaryConfig{
+			Objectives: map[float64]float64{0: 0.01, 1: 0.01, 0.5: 0},
+			AgeBuckets: 1,
+		})
+
+		for i := 0; i < 100000; i++ {
+			s.Observe(float64(i))
+		}
+		for _, q := range s.Snapshot().Quantiles {
+			if math.IsNaN(q.Value) || math.IsInf(q.Value, 0) {
+				t.Errorf("quantile %v = %v, want a finite value", q.Quantile, q.Value)
+			}
+		}
+
+		if got := len(s.streams[0].samples); got > 2000 {
+			t.Errorf("stream holds %d samples after 100000 observations, want bounded", got)
+		}
+	})
+
+	t.Run("quantiles stay within objectives", func(t *testing.T) {
+		objectives := map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+		s := NewSummary("test", SummaryConfig{Objectives: objectives})
+
+		const n = 10000
+		rng := rand.New(rand.NewSource(1))
+		for _, v := range rng.Perm(n) {
+			s.Observe(float64(v + 1))
+		}
+
+		for _, q := range s.Snapshot().Quantiles {
+			// Observations are the ranks 1..n, so the value is the rank.
+			allowed := objectives[q.Quantile] * n
+			if diff := math.Abs(q.Value - q.Quantile*n); diff > allowed {
+				t.Errorf("quantile %v = %v, off by %v ranks, allowed %v", q.Quantile, q.Value, diff, allowed)
+			}
+		}
+	})
+
+	t.Run("memory stays bounded", func(t *testing.T) {
+		s := NewSummary("test", SummaryConfig{AgeBuckets: 1})
+
+		for i := 0; i < 100000; i++ {
+			s.Observe(float64(i))
+		}
+		s.Snapshot()
+
+		if got := len(s.streams[0].samples); got > 2000 {
+			t.Errorf("stream holds %d samples after 100000 observations, want bounded", got)
+		}
+	})
+
+	t.Run("window slides with clock", func(t *testing.T) {
+		clock := newFakeClock()
+		s := NewSummary("test", SummaryConfig{
+			MaxAge:     time.Minute,
+			AgeBuckets: 3,
+			Clock:      clock,
+		})
+
+		s.Observe(100)
+		clock.Advance(30 * time.Second)
+		s.Observe(1)
+
+		if got := s.Snapshot().Quantiles[2].Value; got != 100 {
+			t.Errorf("p99 within window = %v, want 100", got)
+		}
+
+		// Once the first observation ages out, only the second remains.
+		clock.Advance(45 * time.Second)
+		if got := s.Snapshot().Quantiles[2].Value; got != 1 {
+			t.Errorf("p99 after first observation expired = %v, want 1", got)
+		}
+
+		clock.Advance(time.Minute)
+		got := s.Snapshot()
+		if !math.IsNaN(got.Quantiles[0].Value) {
+			t.Errorf("p50 after window expired = %v, want NaN", got.Quantiles[0].Value)
+		}
+		if got.Count != 2 {
+			t.Errorf("Summary count after window expired = %v, want 2", got.Count)
+		}
+	})
+
+	t.Run("Value returns interface{}", func(t *testing.T) {
+		s := NewSummary("test", SummaryConfig{})
+		s.Observe(1)
+
+		value := s.Value()
+		if _, ok := value.(SummarySnapshot); !ok {
+			t.Errorf("Summary.Value() returned type %T, want SummarySnapshot", value)
+		}
+	})
+
+	t.Run("implements Metric interface", func(t *testing.T) {
+		var _ Metric = (*Summary)(nil)
+	})
+}
+
+// TestSummary_Concurrent tests summary operations under concurrent access.
+func TestSummary_Concurrent(t *testing.T) {
+	s := NewSummary("concurrent_test", SummaryConfig{})
+	const goroutines = 50
+	const observations = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines * 2)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < observations; j++ {
+				s.Observe(float64(j))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_ = s.Snapshot()
+		}()
+	}
+
+	wg.Wait()
+
+	if got, want := s.Snapshot().Count, uint64(goroutines*observations); got != want {
+		t.Errorf("concurrent Summary count = %v, want %v", got, want)
+	}
+}
+
+// TestRegistry_Summary tests that summary snapshots pass through the registry.
+func TestRegistry_Summary(t *testing.T) {
+	r := NewRegistry(0)
+	s := NewSummary("rpc_duration_seconds", SummaryConfig{})
+	s.Observe(0.2)
+
+	if err := r.Register(s); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	snapshot, ok := r.Snapshot()["rpc_duration_seconds"].(SummarySnapshot)
+	if !ok {
+		t.Fatalf("snapshot['rpc_duration_seconds'] type = %T, want SummarySnapshot",
+			r.Snapshot()["rpc_duration_seconds"])
+	}
+	if snapshot.Quantiles[0].Value != 0.2 {
+		t.Errorf("snapshot p50 = %v, want 0.2", snapshot.Quantiles[0].Value)
+	}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+// SummaryVec is a family of summaries that share a name and are
+// distinguished by label values, e.g. rpc_duration_seconds{endpoint="GetUser"}.
+// Children are created on first use and cached. It is safe for concurrent
+// use by multiple goroutines.
+type SummaryVec struct {
+	vec *metricVec[*Summary]
+}
+
+// Compile-time verification that SummaryVec implements metricFamily.
+var _ metricFamily = (*SummaryVec)(nil)
+
+// NewSummaryVec creates a new summary vector with the given name, label
+// names, configuration and options. The configuration is shared by all
+// children.
+func NewSummaryVec(name string, labelNames []string, cfg SummaryConfig, opts ...Option) *SummaryVec {
+	desc := newDesc(name, TypeSummary, labelNames, opts)
+	return &SummaryVec{
+		vec: newMetricVec(desc, func(labels Labels) *Summary {
+			return newSummary(&desc, labels, cfg)
+		}),
+	}
+}
+
+// Name returns the name shared by all summaries in this vector.
+func (v *SummaryVec) Name() string {
+	return v.vec.desc.Name
+}
+
+// Desc returns the descriptor shared by all summarys in this vector.
+func (v *SummaryVec) Desc() Desc {
+	return v.vec.desc.clone()
+}
+
+// Type returns TypeSummary, the type of every child in this vector.
+func (v *SummaryVec) Type() MetricType {
+	return TypeSummary
+}
+
+// Value returns the current values of all children as an interface{}.
+// The underlying type is map[string]interface{}, keyed by the children's
+// label sets as rendered by Labels.String, with SummarySnapshot values.
+func (v *SummaryVec) Value() interface{} {
+	return v.vec.value()
+}
+
+// LabelNames returns a copy of the label names declared for this vector.
+func (v *SummaryVec) LabelNames() []string {
+	return append([]string(nil), v.vec.labelNames...)
+}
+
+// WithLabelValues returns the summary for the given label values, given in
+// the order of the declared label names, creating it if needed.
+// It returns ErrLabelMismatch if the number of values is wrong.
+func (v *SummaryVec) WithLabelValues(values ...string) (*Summary, error) {
+	return v.vec.withLabelValues(values)
+}
+
+// With returns the summary for the given labels, creating it if needed.
+// It returns ErrLabelMismatch if the labels differ from the declared names.
+func (v *SummaryVec) With(labels Labels) (*Summary, error) {
+	return v.vec.with(labels)
+}
+
+// Delete removes the summary for the given labels.
+// It reports whether a summary was removed.
+func (v *SummaryVec) Delete(labels Labels) bool {
+	return v.vec.delete(labels)
+}
+
+// Reset removes all summaries from this vector.
+func (v *SummaryVec) Reset() {
+	v.vec.reset()
+}
+
+func (v *SummaryVec) children() []Metric {
+	return v.vec.children()
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import "time"
+
+// Observer is implemented by metrics that record individual observations,
+// such as Histogram and Summary.
+type Observer interface {
+	// Observe records a single observation.
+	Observe(value float64)
+}
+
+// Compile-time verification that the distribution metrics implement
+// Observer interface.
+var (
+	_ Observer = (*Histogram)(nil)
+	_ Observer = (*Summary)(nil)
+)
+
+// ObserverFunc adapts an ordinary function to the Observer interface, e.g.
+// ObserverFunc(gauge.Set) to keep only the latest duration.
+type ObserverFunc func(value float64)
+
+// Observe calls f(value).
+func (f ObserverFunc) Observe(value float64) {
+	f(value)
+}
+
+// Timer measures the time elapsed since its creation and records it, in
+// seconds, into an Observer. A Timer is typically used for a single
+// measurement:
+//
+//	timer := metrics.NewTimer(latency)
+//	defer timer.ObserveDuration()
+type Timer struct {
+	observer Observer
+	clock    Clock
+	start    time.Time
+}
+
+// NewTimer creates a timer that starts now and records into o.
+func NewTimer(o Observer) *Timer {
+	return NewTimerWithClock(o, nil)
+}
+
+// NewTimerWithClock creates a timer that reads time from c, which defaults
+// to the system clock if nil. It starts at c.Now() and records into o.
+func NewTimerWithClock(o Observer, c Clock) *Timer {
+	c = clockOrDefault(c)
+	return &Timer{
+		observer: o,
+		clock:    c,
+		start:    c.Now(),
+	}
+}
+
+// ObserveDuration records the time elapsed since the timer was created, in
+// seconds, and returns it. Each call records a new observation.
+func (t *Timer) ObserveDuration() time.Duration {
+	d := t.clock.Now().Sub(t.start)
+	if t.observer != nil {
+		t.observer.Observe(d.Seconds())
+	}
+	return d
+}
+
+// Time calls f and records its duration, in seconds, into o. The duration
+// is recorded even if f panics.
+func Time(o Observer, f func()) time.Duration {
+	return TimeWithClock(o, nil, f)
+}
+
+// TimeWithClock is like Time but reads time from c, which defaults to the
+// system clock if nil.
+func TimeWithClock(o Observer, c Clock, f func()) (d time.Duration) {
+	t := NewTimerWithClock(o, c)
+	defer func() {
+		d = t.ObserveDuration()
+	}()
+	f()
+	return
+}
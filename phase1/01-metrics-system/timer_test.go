@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+// recordingObserver records every observation.
+type recordingObserver struct {
+	values []float64
+}
+
+func (o *recordingObserver) Observe(value float64) {
+	o.values = append(o.values, value)
+}
+
+// TestTimer tests the Timer implementation.
+func TestTimer(t *testing.T) {
+	t.Run("ObserveDuration records elapsed seconds", func(t *testing.T) {
+		clock := newFakeClock()
+		o := &recordingObserver{}
+
+		timer := NewTimerWithClock(o, clock)
+		clock.Advance(1500 * time.Millisecond)
+
+		if got := timer.ObserveDuration(); got != 1500*time.Millisecond {
+			t.Errorf("ObserveDuration() = %v, want 1.5s", got)
+		}
+		if len(o.values) != 1 || o.values[0] != 1.5 {
+			t.Errorf("observations = %v, want [1.5]", o.values)
+		}
+	})
+
+	t.Run("records into histogram", func(t *testing.T) {
+		clock := newFakeClock()
+		h := NewHistogram("latency_seconds", []float64{0.1, 1})
+
+		timer := NewTimerWithClock(h, clock)
+		clock.Advance(200 * time.Millisecond)
+		timer.ObserveDuration()
+
+		got := h.Snapshot()
+		if got.Count != 1 || got.Buckets[0].Count != 0 || got.Buckets[1].Count != 1 {
+			t.Errorf("histogram after 200ms timing = %+v, want one observation in le=1", got)
+		}
+	})
+
+	t.Run("ObserverFunc adapts gauges", func(t *testing.T) {
+		clock := newFakeClock()
+		g := NewGauge("last_duration_seconds")
+
+		timer := NewTimerWithClock(ObserverFunc(g.Set), clock)
+		clock.Advance(3 * time.Second)
+		timer.ObserveDuration()
+
+		if got := g.Load(); got != 3 {
+			t.Errorf("gauge after timing = %v, want 3", got)
+		}
+	})
+
+	t.Run("nil observer only measures", func(t *testing.T) {
+		clock := newFakeClock()
+		timer := NewTimerWithClock(nil, clock)
+		clock.Advance(time.Second)
+
+		if got := timer.ObserveDuration(); got != time.Second {
+			t.Errorf("ObserveDuration() = %v, want 1s", got)
+		}
+	})
+
+	t.Run("NewTimer uses system clock", func(t *testing.T) {
+		o := &recordingObserver{}
+		if got := NewTimer(o).ObserveDuration(); got < 0 {
+			t.Errorf("ObserveDuration() = %v, want non-negative", got)
+		}
+	})
+}
+
+// TestTime tests the Time wrapper.
+func TestTime(t *testing.T) {
+	t.Run("records duration of f", func(t *testing.T) {
+		clock := newFakeClock()
+		o := &recordingObserver{}
+
+		got := TimeWithClock(o, clock, func() {
+			clock.Advance(250 * time.Millisecond)
+		})
+
+		if got != 250*time.Millisecond {
+			t.Errorf("TimeWithClock() = %v, want 250ms", got)
+		}
+		if len(o.values) != 1 || o.values[0] != 0.25 {
+			t.Errorf("observations = %v, want [0.25]", o.values)
+		}
+	})
+
+	t.Run("records duration when f panics", func(t *testing.T) {
+		clock := newFakeClock()
+		o := &recordingObserver{}
+
+		func() {
+			defer func() { _ = recover() }()
+			TimeWithClock(o, clock, func() {
+				clock.Advance(time.Second)
+				panic("boom")
+			})
+		}()
+
+		if len(o.values) != 1 || o.values[0] != 1 {
+			t.Errorf("observations = %v, want [1]", o.values)
+		}
+	})
+
+	t.Run("Time uses system clock", func(t *testing.T) {
+		o := &recordingObserver{}
+		Time(o, func() {})
+
+		if len(o.values) != 1 {
+			t.Errorf("Time() recorded %d observations, want 1", len(o.values))
+		}
+	})
+}
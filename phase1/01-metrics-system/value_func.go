@@ -0,0 +1,65 @@
+package metrics
+
+import "context"
+
+// GaugeFunc is a gauge whose value is computed by a callback each time the
+// registry is collected, e.g. the length of a queue owned by another
+// component. Register it with Registry.RegisterCollector, which bounds the
+// callback with the collector timeout and recovers from its panics.
+type GaugeFunc struct {
+	desc Desc
+	f    func() float64
+}
+
+// Compile-time verification that GaugeFunc implements Collector.
+var _ Collector = (*GaugeFunc)(nil)
+
+// NewGaugeFunc creates a gauge that reports the value returned by f.
+// f must be safe for concurrent use.
+func NewGaugeFunc(name string, f func() float64, opts ...Option) *GaugeFunc {
+	return &GaugeFunc{
+		desc: newDesc(name, TypeGauge, nil, opts),
+		f:    f,
+	}
+}
+
+// Describe returns the descriptor of the gauge.
+func (g *GaugeFunc) Describe() []Desc {
+	return []Desc{g.desc.clone()}
+}
+
+// Collect calls the callback and returns its value as a single sample.
+func (g *GaugeFunc) Collect(context.Context) ([]Sample, error) {
+	return []Sample{{Name: g.desc.Name, Value: g.f()}}, nil
+}
+
+// CounterFunc is a counter whose value is computed by a callback each time
+// the registry is collected, e.g. a total maintained by another library.
+// The callback must return a value that never decreases. Register it with
+// Registry.RegisterCollector.
+type CounterFunc struct {
+	desc Desc
+	f    func() float64
+}
+
+// Compile-time verification that CounterFunc implements Collector.
+var _ Collector = (*CounterFunc)(nil)
+
+// NewCounterFunc creates a counter that reports the value returned by f.
+// f must be safe for concurrent use.
+func NewCounterFunc(name string, f func() float64, opts ...Option) *CounterFunc {
+	return &CounterFunc{
+		desc: newDesc(name, TypeCounter, nil, opts),
+		f:    f,
+	}
+}
+
+// Describe returns the descriptor of the counter.
+func (c *CounterFunc) Describe() []Desc {
+	return []Desc{c.desc.clone()}
+}
+
+// Collect calls the callback and returns its value as a single sample.
+func (c *CounterFunc) Collect(context.Context) ([]Sample, error) {
+	return []Sample{{Name: c.desc.Name, Value: c.f()}}, nil
+}
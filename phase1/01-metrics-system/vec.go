@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// metricFamily is implemented by metrics that group several labeled
+// children under a single name, such as CounterVec. The registry expands
+// families into their children when taking snapshots.
+type metricFamily interface {
+	Metric
+
+	// children returns the current children, sorted by their labels.
+	children() []Metric
+}
+
+// labelValueSeparator joins label values into a child key. It cannot occur
+// in valid UTF-8 text.
+const labelValueSeparator = "\xff"
+
+// metricVec holds the children of a vector metric, keyed by label values.
+// Children are created on first use and cached. The labels passed to
+// newMetric include the constant labels of desc.
+type metricVec[M Metric] struct {
+	desc       Desc
+	labelNames []string
+	newMetric  func(labels Labels) M
+
+	mu      sync.RWMutex
+	metrics map[string]M
+}
+
+func newMetricVec[M Metric](desc Desc, newMetric func(Labels) M) *metricVec[M] {
+	return &metricVec[M]{
+		desc:       desc,
+		labelNames: desc.VariableLabels,
+		newMetric:  newMetric,
+		metrics:    make(map[string]M),
+	}
+}
+
+// withLabelValues returns the child for the given label values, in the
+// order of the declared label names, creating it if needed.
+func (v *metricVec[M]) withLabelValues(values []string) (M, error) {
+	if len(values) != len(v.labelNames) {
+		var zero M
+		return zero, fmt.Errorf("%w: %s: expected %d label values, got %d",
+			ErrLabelMismatch, v.desc.Name, len(v.labelNames), len(values))
+	}
+	return v.getOrCreate(values), nil
+}
+
+// with returns the child for the given labels, creating it if needed.
+func (v *metricVec[M]) with(labels Labels) (M, error) {
+	values, err := v.valuesFor(labels)
+	if err != nil {
+		var zero M
+		return zero, err
+	}
+	return v.getOrCreate(values), nil
+}
+
+// delete removes the child for the given labels.
+// It reports whether a child was removed.
+func (v *metricVec[M]) delete(labels Labels) bool {
+	values, err := v.valuesFor(labels)
+	if err != nil {
+		return false
+	}
+	key := strings.Join(values, labelValueSeparator)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, exists := v.metrics[key]; !exists {
+		return false
+	}
+	delete(v.metrics, key)
+	return true
+}
+
+// reset removes all children.
+func (v *metricVec[M]) reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.metrics = make(map[string]M)
+}
+
+// children returns the current children sorted by label values.
+func (v *metricVec[M]) children() []Metric {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.metrics))
+	for key := range v.metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	children := make([]Metric, len(keys))
+	for i, key := range keys {
+		children[i] = v.metrics[key]
+	}
+	v.mu.RUnlock()
+
+	return children
+}
+
+// value returns the children's values keyed by their label sets.
+func (v *metricVec[M]) value() map[string]interface{} {
+	children := v.children()
+	values := make(map[string]interface{}, len(children))
+	for _, child := range children {
+		values[labelsOf(child).String()] = child.Value()
+	}
+	return values
+}
+
+// valuesFor returns the variable label values in labels, in declaration
+// order. Constant labels may be included in labels as long as their values
+// match, so that the labels of a child select that child.
+func (v *metricVec[M]) valuesFor(labels Labels) ([]string, error) {
+	n := len(labels)
+	for name, value := range v.desc.ConstLabels {
+		if got, ok := labels[name]; ok && got == value {
+			n--
+		}
+	}
+	if n != len(v.labelNames) {
+		return nil, fmt.Errorf("%w: %s: expected labels %v, got %v",
+			ErrLabelMismatch, v.desc.Name, v.labelNames, labels.names())
+	}
+
+	values := make([]string, len(v.labelNames))
+	for i, name := range v.labelNames {
+		value, ok := labels[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: missing label %q",
+				ErrLabelMismatch, v.desc.Name, name)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (v *metricVec[M]) getOrCreate(values []string) M {
+	key := strings.Join(values, labelValueSeparator)
+
+	v.mu.RLock()
+	m, exists := v.metrics[key]
+	v.mu.RUnlock()
+	if exists {
+		return m
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Another goroutine may have created the child while we were unlocked.
+	if m, exists := v.metrics[key]; exists {
+		return m
+	}
+
+	if v.metrics == nil {
+		v.metrics = make(map[string]M)
+	}
+
+	labels := make(Labels, len(values))
+	for i, name := range v.labelNames {
+		labels[name] = values[i]
+	}
+
+	m = v.newMetric(mergeLabels(v.desc.ConstLabels, labels))
+	v.metrics[key] = m
+	return m
+}
+
+// labelsOf returns the labels of a metric, or nil if it has none.
+func labelsOf(m Metric) Labels {
+	if l, ok := m.(interface{ Labels() Labels }); ok {
+		return l.Labels()
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package metrics
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestLabels_String tests rendering of label sets.
+func TestLabels_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels Labels
+		want   string
+	}{
+		{
+			name:   "empty",
+			labels: nil,
+			want:   "",
+		},
+		{
+			name:   "sorted by name",
+			labels: Labels{"status": "200", "method": "GET"},
+			want:   `{method="GET",status="200"}`,
+		},
+		{
+			name:   "escapes values",
+			labels: Labels{"path": "a\\b\"c\nd"},
+			want:   `{path="a\\b\"c\nd"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.labels.String(); got != tt.want {
+				t.Errorf("Labels.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCounterVec tests the CounterVec implementation.
+func TestCounterVec(t *testing.T) {
+	t.Run("NewCounterVec creates vector with name", func(t *testing.T) {
+		v := NewCounterVec("http_requests_total", []string{"method", "status"})
+
+		if got := v.Name(); got != "http_requests_total" {
+			t.Errorf("CounterVec.Name() = %v, want http_requests_total", got)
+		}
+		if got := v.Type(); got != TypeCounter {
+			t.Errorf("CounterVec.Type() = %v, want %v", got, TypeCounter)
+		}
+		if got := v.LabelNames(); !reflect.DeepEqual(got, []string{"method", "status"}) {
+			t.Errorf("CounterVec.LabelNames() = %v, want [method status]", got)
+		}
+	})
+
+	t.Run("WithLabelValues caches children", func(t *testing.T) {
+		v := NewCounterVec("test", []string{"method", "status"})
+
+		c1, err := v.WithLabelValues("GET", "200")
+		if err != nil {
+			t.Fatalf("WithLabelValues() error = %v", err)
+		}
+		c1.Inc()
+
+		c2, err := v.With(Labels{"status": "200", "method": "GET"})
+		if err != nil {
+			t.Fatalf("With() error = %v", err)
+		}
+		if c1 != c2 {
+			t.Error("WithLabelValues() and With() returned different children for the same labels")
+		}
+
+		if got := c2.Labels(); !reflect.DeepEqual(got, Labels{"method": "GET", "status": "200"}) {
+			t.Errorf("child Labels() = %v, want method=GET status=200", got)
+		}
+		if got := c2.Name(); got != "test" {
+			t.Errorf("child Name() = %v, want test", got)
+		}
+	})
+
+	t.Run("rejects mismatched labels", func(t *testing.T) {
+		v := NewCounterVec("test", []string{"method", "status"})
+
+		tests := []struct {
+			name string
+			call func() error
+		}{
+			{
+				name: "too few values",
+				call: func() error { _, err := v.WithLabelValues("GET"); return err },
+			},
+			{
+				name: "too many values",
+				call: func() error { _, err := v.WithLabelValues("GET", "200", "x"); return err },
+			},
+			{
+				name: "unknown label name",
+				call: func() error { _, err := v.With(Labels{"method": "GET", "code": "200"}); return err },
+			},
+			{
+				name: "missing label",
+				call: func() error { _, err := v.With(Labels{"method": "GET"}); return err },
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := tt.call(); !errors.Is(err, ErrLabelMismatch) {
+					t.Errorf("error = %v, want ErrLabelMismatch", err)
+				}
+			})
+		}
+	})
+
+	t.Run("Delete and Reset remove children", func(t *testing.T) {
+		v := NewCounterVec("test", []string{"method"})
+		_, _ = v.WithLabelValues("GET")
+		_, _ = v.WithLabelValues("POST")
+
+		if !v.Delete(Labels{"method": "GET"}) {
+			t.Error("Delete() of existing child returned false")
+		}
+		if v.Delete(Labels{"method": "GET"}) {
+			t.Error("Delete() of missing child returned true")
+		}
+		if got := len(v.children()); got != 1 {
+			t.Errorf("after Delete(), %d children, want 1", got)
+		}
+
+		v.Reset()
+		if got := len(v.children()); got != 0 {
+			t.Errorf("after Reset(), %d children, want 0", got)
+		}
+	})
+
+	t.Run("Value returns values keyed by labels", func(t *testing.T) {
+		v := NewCounterVec("test", []string{"method"})
+		c, _ := v.WithLabelValues("GET")
+		c.Add(3)
+
+		want := map[string]interface{}{`{method="GET"}`: int64(3)}
+		if got := v.Value(); !reflect.DeepEqual(got, want) {
+			t.Errorf("CounterVec.Value() = %v, want %v", got, want)
+		}
+	})
+}
+
+// TestCounterVec_Concurrent tests concurrent child creation.
+func TestCounterVec_Concurrent(t *testing.T) {
+	v := NewCounterVec("concurrent_test", []string{"worker"})
+	const goroutines = 100
+	const increments = 100
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		i := i // capture loop variable
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				c, err := v.WithLabelValues([]string{"a", "b", "c", "d"}[i%4])
+				if err != nil {
+					t.Errorf("WithLabelValues() error = %v", err)
+					return
+				}
+				c.Inc()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	children := v.children()
+	if len(children) != 4 {
+		t.Fatalf("concurrent CounterVec has %d children, want 4", len(children))
+	}
+
+	var total int64
+	for _, child := range children {
+		total += child.(*Counter).Load()
+	}
+	if want := int64(goroutines * increments); total != want {
+		t.Errorf("concurrent CounterVec total = %v, want %v", total, want)
+	}
+}
+
+// TestVecs tests the gauge, histogram and summary vectors.
+func TestVecs(t *testing.T) {
+	t.Run("GaugeVec", func(t *testing.T) {
+		v := NewGaugeVec("queue_depth", []string{"queue"})
+		g, err := v.WithLabelValues("jobs")
+		if err != nil {
+			t.Fatalf("WithLabelValues() error = %v", err)
+		}
+		g.Set(4)
+
+		if got := v.Type(); got != TypeGauge {
+			t.Errorf("GaugeVec.Type() = %v, want %v", got, TypeGauge)
+		}
+		if got := v.Value(); !reflect.DeepEqual(got, map[string]interface{}{`{queue="jobs"}`: 4.0}) {
+			t.Errorf("GaugeVec.Value() = %v", got)
+		}
+	})
+
+	t.Run("HistogramVec shares buckets", func(t *testing.T) {
+		v := NewHistogramVec("latency_seconds", []string{"route"}, []float64{2, 1})
+		h, err := v.WithLabelValues("/users")
+		if err != nil {
+			t.Fatalf("WithLabelValues() error = %v", err)
+		}
+		h.Observe(1.5)
+
+		if got := v.Type(); got != TypeHistogram {
+			t.Errorf("HistogramVec.Type() = %v, want %v", got, TypeHistogram)
+		}
+		want := []Bucket{{UpperBound: 1, Count: 0}, {UpperBound: 2, Count: 1}}
+		if got := h.Snapshot().Buckets; !reflect.DeepEqual(got, want) {
+			t.Errorf("child buckets = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("SummaryVec", func(t *testing.T) {
+		v := NewSummaryVec("rpc_seconds", []string{"endpoint"}, SummaryConfig{})
+		s, err := v.With(Labels{"endpoint": "GetUser"})
+		if err != nil {
+			t.Fatalf("With() error = %v", err)
+		}
+		s.Observe(0.3)
+
+		if got := v.Type(); got != TypeSummary {
+			t.Errorf("SummaryVec.Type() = %v, want %v", got, TypeSummary)
+		}
+		if got := s.Snapshot().Count; got != 1 {
+			t.Errorf("child count = %v, want 1", got)
+		}
+	})
+}
+
+// TestRegistry_Vec tests that vector children appear in snapshots.
+func TestRegistry_Vec(t *testing.T) {
+	r := NewRegistry(0)
+	v := NewCounterVec("http_requests_total", []string{"method", "status"})
+
+	if err := r.Register(v); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	get, _ := v.WithLabelValues("GET", "200")
+	get.Add(2)
+	post, _ := v.WithLabelValues("POST", "500")
+	post.Inc()
+
+	want := map[string]interface{}{
+		`http_requests_total{method="GET",status="200"}`:  int64(2),
+		`http_requests_total{method="POST",status="500"}`: int64(1),
+	}
+	if got := r.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %v, want %v", got, want)
+	}
+}
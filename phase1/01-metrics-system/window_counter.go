@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWindow is the default window over which a WindowCounter
+	// counts events.
+	DefaultWindow = time.Minute
+
+	// DefaultWindowBuckets is the default number of buckets the window of
+	// a WindowCounter is divided into.
+	DefaultWindowBuckets = 6
+)
+
+// WindowCounterConfig configures a WindowCounter. The zero value selects
+// the defaults.
+type WindowCounterConfig struct {
+	// Window is the duration over which events are counted.
+	// Defaults to DefaultWindow.
+	Window time.Duration
+
+	// Buckets is the number of buckets the window is divided into. The
+	// window slides forward one bucket at a time, so more buckets make
+	// the count more precise at the cost of memory.
+	// Defaults to DefaultWindowBuckets.
+	Buckets int
+
+	// Clock is the time source for the sliding window.
+	// Defaults to the system clock.
+	Clock Clock
+}
+
+// WindowCounter is a metric that counts the events of a sliding time
+// window, such as the requests of the last minute, for rate limiting and
+// alerting. Unlike a Counter, its value decreases as events fall out of the
+// window, so it is exported as a gauge.
+//
+// The window is divided into buckets aligned to multiples of the bucket
+// duration and slides one bucket at a time: the count covers the current,
+// partial bucket and the Buckets-1 buckets before it, that is between
+// Window-Window/Buckets and Window of history. Memory is bounded by the
+// number of buckets.
+//
+// A WindowCounter is safe for concurrent use by multiple goroutines. The
+// zero value is ready to use with the default configuration.
+type WindowCounter struct {
+	name   string
+	desc   *Desc
+	labels Labels
+	updateTracker
+
+	mu          sync.Mutex
+	cfg         WindowCounterConfig
+	initialized bool
+	clock       Clock
+	width       time.Duration // of a bucket
+	buckets     []int64       // ring of counts, indexed by bucket number
+	head        int64         // number of the current bucket
+	total       int64         // sum of buckets
+}
+
+// Compile-time verification that WindowCounter implements Metric interface.
+var _ Metric = (*WindowCounter)(nil)
+
+// NewWindowCounter creates a new sliding-window counter metric with the
+// given name, configuration and options.
+func NewWindowCounter(name string, cfg WindowCounterConfig, opts ...Option) *WindowCounter {
+	desc := newDesc(name, TypeGauge, nil, opts)
+	return &WindowCounter{
+		name:   name,
+		desc:   &desc,
+		labels: desc.ConstLabels,
+		cfg:    cfg,
+	}
+}
+
+// Name returns the name of this window counter metric.
+func (c *WindowCounter) Name() string {
+	return c.name
+}
+
+// Desc returns the descriptor of this window counter.
+func (c *WindowCounter) Desc() Desc {
+	return describe(c.name, TypeGauge, c.desc)
+}
+
+// Labels returns a copy of the constant labels of this window counter.
+func (c *WindowCounter) Labels() Labels {
+	return c.labels.clone()
+}
+
+// Type returns TypeGauge, since the count of a window can decrease.
+func (c *WindowCounter) Type() MetricType {
+	return TypeGauge
+}
+
+// Value returns the number of events in the window as an interface{}.
+// The underlying type is int64.
+func (c *WindowCounter) Value() interface{} {
+	return c.Load()
+}
+
+// Inc records one event.
+// This operation is safe for concurrent use.
+func (c *WindowCounter) Inc() {
+	c.Add(1)
+}
+
+// Add records delta events.
+// Delta must be non-negative. Negative values are treated as 0.
+// This operation is safe for concurrent use.
+func (c *WindowCounter) Add(delta int64) {
+	if delta < 0 {
+		delta = 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.initLocked()
+	c.advanceLocked()
+	c.buckets[c.slot(c.head)] += delta
+	c.total += delta
+	c.touch()
+}
+
+// Load returns the number of events in the window.
+func (c *WindowCounter) Load() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.initLocked()
+	c.advanceLocked()
+	return c.total
+}
+
+// initLocked applies defaults on first use. c.mu must be held.
+func (c *WindowCounter) initLocked() {
+	if c.initialized {
+		return
+	}
+	c.initialized = true
+
+	window := c.cfg.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	buckets := c.cfg.Buckets
+	if buckets <= 0 {
+		buckets = DefaultWindowBuckets
+	}
+
+	c.clock = clockOrDefault(c.cfg.Clock)
+	c.width = window / time.Duration(buckets)
+	if c.width <= 0 {
+		c.width = 1
+	}
+	c.buckets = make([]int64, buckets)
+	c.head = c.bucketOf(c.clock.Now())
+}
+
+// advanceLocked slides the window to the current time, clearing the
+// buckets that fell out of it. c.mu must be held.
+func (c *WindowCounter) advanceLocked() {
+	now := c.bucketOf(c.clock.Now())
+	if now <= c.head {
+		return
+	}
+
+	n := int64(len(c.buckets))
+	if now-c.head >= n {
+		// The whole window has passed.
+		clear(c.buckets)
+		c.total = 0
+	} else {
+		for b := c.head + 1; b <= now; b++ {
+			c.total -= c.buckets[c.slot(b)]
+			c.buckets[c.slot(b)] = 0
+		}
+	}
+	c.head = now
+}
+
+// bucketOf returns the number of the bucket that contains t. It rounds
+// down, so that times before 1970 fall in negative buckets of the same
+// width.
+func (c *WindowCounter) bucketOf(t time.Time) int64 {
+	b := t.UnixNano() / int64(c.width)
+	if t.UnixNano()%int64(c.width) < 0 {
+		b--
+	}
+	return b
+}
+
+// slot returns the index in c.buckets of bucket b, which may be negative.
+func (c *WindowCounter) slot(b int64) int64 {
+	n := int64(len(c.buckets))
+	return (b%n + n) % n
+}
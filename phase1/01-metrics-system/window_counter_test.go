@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWindowCounter tests counting over a sliding window.
+func TestWindowCounter(t *testing.T) {
+	clock := newFakeClock()
+	c := NewWindowCounter("requests", WindowCounterConfig{
+		Window:  time.Minute,
+		Buckets: 6,
+		Clock:   clock,
+	})
+
+	steps := []struct {
+		advance time.Duration
+		add     int64
+		want    int64
+	}{
+		{0, 5, 5},
+		{10 * time.Second, 3, 8},
+		{45 * time.Second, 0, 8}, // 55s: both buckets still in the window
+		{5 * time.Second, 0, 3},  // 60s: the first bucket falls out
+		{9 * time.Second, -4, 3}, // 69s: negative values are ignored
+		{time.Second, 1, 1},      // 70s: the second bucket falls out
+		{2 * time.Minute, 0, 0},  // the whole window passed
+		{time.Hour, 7, 7},        // and again, long after
+		{59 * time.Second, 0, 7}, // last instant of the bucket's window
+		{time.Second, 0, 0},
+	}
+
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		c.Add(step.add)
+		if got := c.Load(); got != step.want {
+			t.Errorf("step %d: Load() = %d, want %d", i, got, step.want)
+		}
+	}
+}
+
+// TestWindowCounter_BeforeEpoch tests a clock before 1970, whose bucket
+// numbers are negative, and crossing into 1970.
+func TestWindowCounter_BeforeEpoch(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(-100, 0)}
+	c := NewWindowCounter("requests", WindowCounterConfig{
+		Window:  time.Minute,
+		Buckets: 6,
+		Clock:   clock,
+	})
+
+	steps := []struct {
+		advance time.Duration
+		add     int64
+		want    int64
+	}{
+		{0, 5, 5},                // -100s
+		{50 * time.Second, 3, 8}, // -50s
+		{40 * time.Second, 0, 3}, // -10s: the first bucket falls out
+		{20 * time.Second, 1, 1}, // 10s: the second bucket falls out
+	}
+
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		c.Add(step.add)
+		if got := c.Load(); got != step.want {
+			t.Errorf("step %d: Load() = %d, want %d", i, got, step.want)
+		}
+	}
+}
+
+// TestWindowCounter_ZeroValue tests that the zero value is usable.
+func TestWindowCounter_ZeroValue(t *testing.T) {
+	var c WindowCounter
+	c.Inc()
+	c.Inc()
+
+	if got := c.Load(); got != 2 {
+		t.Errorf("Load() = %d, want 2", got)
+	}
+	if got := c.Value(); got != int64(2) {
+		t.Errorf("Value() = %v (%T), want int64 2", got, got)
+	}
+}
+
+// TestWindowCounter_Concurrent tests concurrent increments and reads.
+func TestWindowCounter_Concurrent(t *testing.T) {
+	const goroutines, increments = 8, 1000
+
+	clock := newFakeClock()
+	c := NewWindowCounter("events", WindowCounterConfig{Clock: clock})
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				c.Inc()
+				_ = c.Load()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.Load(); got != goroutines*increments {
+		t.Errorf("Load() = %d, want %d", got, goroutines*increments)
+	}
+}
+
+// TestRegistry_WindowCounter tests that a window counter is exported as a
+// gauge.
+func TestRegistry_WindowCounter(t *testing.T) {
+	clock := newFakeClock()
+	r := NewRegistry(0)
+	c := NewWindowCounter("logins_last_minute", WindowCounterConfig{Clock: clock},
+		WithHelp("Logins in the last minute."))
+	if err := r.Register(c); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	c.Add(4)
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, r); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	want := `# HELP logins_last_minute Logins in the last minute.
+# TYPE logins_last_minute gauge
+logins_last_minute 4
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+
+	clock.Advance(time.Minute)
+	if got := r.Snapshot()["logins_last_minute"]; got != int64(0) {
+		t.Errorf("Snapshot() after a minute = %v, want 0", got)
+	}
+}